@@ -8,6 +8,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kaganyuksek/gotosleep/internal/app"
+	"github.com/kaganyuksek/gotosleep/internal/cli"
 )
 
 var (
@@ -17,6 +18,18 @@ var (
 )
 
 func main() {
+	// Global flags choose the files for both the TUI and the subcommands
+	args, err := cli.ApplyGlobalFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitUsage)
+	}
+
+	// Subcommands run headless without the TUI
+	if len(args) > 0 {
+		os.Exit(cli.New().Run(args))
+	}
+
 	// Initialize the application
 	model, err := app.NewApp()
 	if err != nil {
@@ -3,9 +3,11 @@ module github.com/kaganyuksek/gotosleep
 go 1.21
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/charmbracelet/bubbles v0.18.0
 	github.com/charmbracelet/bubbletea v0.25.0
 	github.com/charmbracelet/lipgloss v0.9.1
+	github.com/godbus/dbus/v5 v5.1.0
 )
 
 require (
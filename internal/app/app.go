@@ -1,12 +1,16 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/daemon"
 	"github.com/kaganyuksek/gotosleep/internal/i18n"
+	"github.com/kaganyuksek/gotosleep/internal/notify"
+	"github.com/kaganyuksek/gotosleep/internal/scheduler"
 	"github.com/kaganyuksek/gotosleep/internal/shutdown"
 	"github.com/kaganyuksek/gotosleep/internal/ui"
 	"github.com/kaganyuksek/gotosleep/internal/utils"
@@ -21,18 +25,26 @@ const (
 	ScreenActive
 	ScreenHistory
 	ScreenSettings
+	ScreenSchedules
 )
 
 // App represents the main application model
 type App struct {
-	config   *config.Config
-	executor shutdown.Executor
-	screen   Screen
-	home     ui.HomeModel
-	confirm  ui.ConfirmModel
-	active   ui.ActiveModel
-	history  ui.HistoryModel
-	settings ui.SettingsModel
+	config    *config.Config
+	control   scheduler.Controller
+	daemon    bool      // true when the timer is owned by the gts daemon
+	probed    time.Time // when the app last looked for a daemon
+	screen    Screen
+	home      ui.HomeModel
+	confirm   ui.ConfirmModel
+	active    ui.ActiveModel
+	history   ui.HistoryModel
+	settings  ui.SettingsModel
+	schedules ui.SchedulesModel
+
+	warner        *notify.Warner // nil when notifications are unavailable
+	notifications chan notify.Action
+
 	err      string
 	quitting bool
 	width    int
@@ -51,16 +63,30 @@ func NewApp() (*App, error) {
 		return nil, fmt.Errorf("failed to initialize i18n: %w", err)
 	}
 
-	executor := shutdown.NewExecutor()
+	control := daemon.Connect(cfg)
+	_, usesDaemon := control.(*daemon.Client)
+
+	// Warnings are best effort, the timer works without a notification service
+	notifications := make(chan notify.Action)
+	var warner *notify.Warner
+	if notifier, err := notify.New(func(action notify.Action) { notifications <- action }); err == nil {
+		warner = notify.NewWarner(notifier)
+	}
 
 	return &App{
-		config:   cfg,
-		executor: executor,
-		screen:   ScreenHome,
-		home:     ui.NewHomeModel(cfg),
-		active:   ui.NewActiveModel(cfg),
-		history:  ui.NewHistoryModel(cfg),
-		settings: ui.NewSettingsModel(cfg),
+		config:    cfg,
+		control:   control,
+		daemon:    usesDaemon,
+		probed:    time.Now(),
+		screen:    ScreenHome,
+		home:      ui.NewHomeModel(cfg),
+		active:    ui.NewActiveModel(cfg),
+		history:   ui.NewHistoryModel(cfg),
+		settings:  ui.NewSettingsModel(cfg),
+		schedules: ui.NewSchedulesModel(cfg),
+
+		warner:        warner,
+		notifications: notifications,
 	}, nil
 }
 
@@ -75,9 +101,9 @@ func (a *App) Init() tea.Cmd {
 	// If there's an active job, go to active screen
 	if a.config.ActiveJob != nil {
 		a.screen = ScreenActive
-		return tea.Batch(setTitle, a.active.Init())
+		return tea.Batch(setTitle, a.active.Init(), syncTick(), waitForNotification(a.notifications))
 	}
-	return tea.Batch(setTitle, a.home.Init())
+	return tea.Batch(setTitle, a.home.Init(), syncTick(), waitForNotification(a.notifications))
 }
 
 // Update handles messages and updates the application state
@@ -86,6 +112,12 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// Handle global messages
 	switch msg := msg.(type) {
+	case syncMsg:
+		return a, a.sync()
+
+	case notificationMsg:
+		return a, a.handleNotification(notify.Action(msg))
+
 	case tea.WindowSizeMsg:
 		// Store and propagate size to all screens
 		a.width = msg.Width
@@ -95,6 +127,7 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.active, _ = a.active.Update(msg)
 		a.history, _ = a.history.Update(msg)
 		a.settings, _ = a.settings.Update(msg)
+		a.schedules, _ = a.schedules.Update(msg)
 		// Force re-render
 		return a, tea.ClearScreen
 
@@ -122,6 +155,8 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a.updateHistory(msg)
 	case ScreenSettings:
 		return a.updateSettings(msg)
+	case ScreenSchedules:
+		return a.updateSchedules(msg)
 	}
 
 	return a, cmd
@@ -144,6 +179,8 @@ func (a *App) View() string {
 		return a.history.View()
 	case ScreenSettings:
 		return a.settings.View()
+	case ScreenSchedules:
+		return a.schedules.View()
 	}
 
 	return ""
@@ -166,6 +203,13 @@ func (a *App) updateHome(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.screen = ScreenSettings
 			a.settings.Refresh(a.config)
 			return a, nil
+		case "r":
+			// Go to schedules, "r" may also be typed into a time such as "tomorrow"
+			if !a.home.IsInputFocused() {
+				a.screen = ScreenSchedules
+				a.schedules.Refresh(a.config)
+				return a, nil
+			}
 		case "a":
 			// Go to active screen if there's an active job
 			if a.config.ActiveJob != nil {
@@ -174,25 +218,29 @@ func (a *App) updateHome(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return a, a.active.Init()
 			}
 		case "enter":
-			// Try to get duration
-			minutes, err := a.home.GetSelectedDuration()
+			// Try to get duration or absolute time
+			target, err := a.home.GetSelectedTarget()
 			if err != nil {
 				a.home, cmd = a.home.Update(msg)
 				return a, cmd
 			}
+			action := a.home.GetSelectedAction()
 
 			// Check if confirmation is enabled in settings
 			if a.config.Settings.Confirm {
 				// Show confirm dialog with DryRunDefault from settings
-				a.confirm = ui.NewConfirmModel(minutes, a.config.Settings.DryRunDefault)
+				a.confirm = ui.NewConfirmModel(target, action, a.config.Settings.DryRunDefault)
+				a.confirm.SetWindows(a.config.Settings.ForbiddenWindows)
+				a.confirm.SetHooks(a.config.Settings, a.daemon)
 				a.screen = ScreenConfirm
 				return a, nil
 			} else {
 				// Skip confirmation and start immediately with DryRunDefault setting
-				err := a.startShutdown(minutes, a.config.Settings.DryRunDefault)
+				err := a.startShutdown(target, action, a.config.Settings.DryRunDefault)
 				if err != nil {
 					a.err = err.Error()
 					a.home.Reset()
+					a.home.SetError(a.err)
 					return a, nil
 				}
 				// Go to active screen
@@ -217,12 +265,12 @@ func (a *App) updateConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Check if user confirmed or cancelled
 	if a.confirm.IsConfirmed() {
 		// Start the shutdown
-		minutes, _ := a.home.GetSelectedDuration()
-		err := a.startShutdown(minutes, a.confirm.IsDryRun())
+		err := a.startShutdown(a.confirm.Target(), a.confirm.Action(), a.confirm.IsDryRun())
 		if err != nil {
 			a.err = err.Error()
 			a.screen = ScreenHome
 			a.home.Reset()
+			a.home.SetError(a.err)
 			return a, nil
 		}
 
@@ -247,9 +295,31 @@ func (a *App) updateConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (a *App) updateActive(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	// The custom adjustment input takes all keys while open
+	if a.active.IsAdjusting() {
+		a.active, cmd = a.active.Update(msg)
+		if delta, ok := a.active.PendingAdjustment(); ok {
+			a.active.ClearAdjustment()
+			a.extendShutdown(delta)
+		}
+		return a, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
+		case "+", "=":
+			a.extendShutdown(5 * time.Minute)
+			return a, nil
+		case "-":
+			a.extendShutdown(-5 * time.Minute)
+			return a, nil
+		case "]":
+			a.extendShutdown(15 * time.Minute)
+			return a, nil
+		case "[":
+			a.extendShutdown(-15 * time.Minute)
+			return a, nil
 		case "c":
 			// Cancel shutdown
 			err := a.cancelShutdown()
@@ -303,17 +373,20 @@ func (a *App) updateHistory(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Restart selected history item
 			selected := a.history.GetSelectedHistory()
 			if selected != nil {
-				minutes := selected.DurationSeconds / 60
+				target := utils.Target{Minutes: selected.DurationSeconds / 60}
+				action := shutdown.ActionOrDefault(selected.Action)
 
 				// Check if confirmation is enabled in settings
 				if a.config.Settings.Confirm {
 					// Use DryRunDefault from settings
-					a.confirm = ui.NewConfirmModel(minutes, a.config.Settings.DryRunDefault)
+					a.confirm = ui.NewConfirmModel(target, action, a.config.Settings.DryRunDefault)
+					a.confirm.SetWindows(a.config.Settings.ForbiddenWindows)
+					a.confirm.SetHooks(a.config.Settings, a.daemon)
 					a.screen = ScreenConfirm
 					return a, nil
 				} else {
 					// Skip confirmation and start immediately with DryRunDefault setting
-					err := a.startShutdown(minutes, a.config.Settings.DryRunDefault)
+					err := a.startShutdown(target, action, a.config.Settings.DryRunDefault)
 					if err != nil {
 						a.err = err.Error()
 						return a, nil
@@ -328,9 +401,15 @@ func (a *App) updateHistory(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Delete selected history item
 			selected := a.history.GetSelectedHistory()
 			if selected != nil {
-				a.config.DeleteHistory(selected.ID)
-				a.config.Save()
-				a.history.Refresh(a.config)
+				// Delete from the latest history, other instances may have added entries
+				err := config.Update(func(cfg *config.Config) error {
+					cfg.DeleteHistory(selected.ID)
+					return nil
+				})
+				if err != nil {
+					a.err = err.Error()
+				}
+				a.reload()
 				return a, nil
 			}
 		}
@@ -352,7 +431,7 @@ func (a *App) updateSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "esc":
 			// Save settings and go back to home
-			a.config.Save()
+			a.save()
 			a.screen = ScreenHome
 			return a, nil
 		}
@@ -366,98 +445,111 @@ func (a *App) updateSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	// Always save settings after update (for toggles)
-	a.config.Save()
+	a.save()
 
 	return a, cmd
 }
 
-// startShutdown starts a shutdown timer
-func (a *App) startShutdown(minutes int, dryRun bool) error {
-	// Cancel any existing job first
-	if a.config.ActiveJob != nil {
-		_ = a.executor.Cancel(dryRun)
-	}
-
-	// Calculate job info
-	jobInfo := shutdown.CalculateJobInfo(minutes)
+// updateSchedules handles updates for the schedules screen
+func (a *App) updateSchedules(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
 
-	// Schedule shutdown
-	command, err := a.executor.Schedule(minutes, dryRun)
-	if err != nil {
-		// Add to history as failed
-		h := config.History{
-			ID:              utils.GenerateID(),
-			CreatedAt:       time.Now(),
-			DurationSeconds: minutes * 60,
-			ScheduledFor:    jobInfo.EndTime,
-			Status:          config.StatusFailed,
-			OS:              a.executor.GetOS(),
-			Command:         command,
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// Esc closes the edit form first
+		if msg.String() == "esc" && !a.schedules.IsEditing() {
+			a.save()
+			a.screen = ScreenHome
+			return a, nil
 		}
-		a.config.AddHistory(h)
-		a.config.Save()
-		return err
 	}
 
-	// Update config with active job
-	a.config.ActiveJob = &config.ActiveJob{
-		StartTime:   jobInfo.StartTime,
-		EndTime:     jobInfo.EndTime,
-		DurationSec: jobInfo.DurationSec,
-		Command:     command,
-	}
+	a.schedules, cmd = a.schedules.Update(msg)
+
+	// Always save after update, the daemon picks schedules up from the state file
+	a.save()
 
-	// Add to history
-	status := config.StatusOK
-	if dryRun {
-		status = config.StatusDryRun
+	return a, cmd
+}
+
+// save writes the config to the state file. When another instance changed
+// the file since it was loaded, the settings, presets and schedules edited
+// here are carried over to the newer file instead.
+func (a *App) save() {
+	err := a.config.Save()
+	if errors.Is(err, config.ErrModified) {
+		edited := *a.config
+		err = config.Update(func(cfg *config.Config) error {
+			cfg.Settings, cfg.Presets = edited.Settings, edited.Presets
+			cfg.Schedules = mergeSchedules(edited.Schedules, cfg.Schedules)
+			return nil
+		})
+		a.reload()
 	}
-	h := config.History{
-		ID:              utils.GenerateID(),
-		CreatedAt:       jobInfo.StartTime,
-		DurationSeconds: jobInfo.DurationSec,
-		ScheduledFor:    jobInfo.EndTime,
-		Status:          status,
-		OS:              a.executor.GetOS(),
-		Command:         command,
+	if err != nil {
+		a.err = err.Error()
 	}
-	a.config.AddHistory(h)
+}
 
-	// Save config
-	return a.config.Save()
+// mergeSchedules returns the edited schedules, keeping the latest occurrence
+// the daemon started of each from the schedules in the state file
+func mergeSchedules(edited, saved []config.Schedule) []config.Schedule {
+	merged := append([]config.Schedule(nil), edited...)
+	for i := range merged {
+		for _, s := range saved {
+			if s.ID == merged[i].ID && s.LastArmed.After(merged[i].LastArmed) {
+				merged[i].LastArmed = s.LastArmed
+			}
+		}
+	}
+	return merged
 }
 
-// cancelShutdown cancels the current shutdown timer
-func (a *App) cancelShutdown() error {
-	if a.config.ActiveJob == nil {
-		return nil
+// startShutdown starts a shutdown timer, refused or pushed back when it
+// would end inside a forbidden window
+func (a *App) startShutdown(target utils.Target, action shutdown.Action, dryRun bool) error {
+	endTime, err := scheduler.CheckWindows(a.config.Settings.ForbiddenWindows, target.EndTime(time.Now()))
+	if err != nil {
+		return err
 	}
 
-	// Determine if it was a dry-run
-	dryRun := false
-	if len(a.config.History) > 0 && a.config.History[0].Status == config.StatusDryRun {
-		dryRun = true
+	err = a.control.StartAt(endTime, action, dryRun)
+	if a.daemon || errors.Is(err, config.ErrModified) {
+		a.reload()
+	}
+	return err
+}
+
+// extendShutdown moves the end of the current shutdown timer by delta
+func (a *App) extendShutdown(delta time.Duration) {
+	err := a.control.Extend(delta)
+	if a.daemon || errors.Is(err, config.ErrModified) {
+		a.reload()
 	}
 
-	// Cancel the shutdown
-	err := a.executor.Cancel(dryRun)
+	a.active.Refresh(a.config)
 	if err != nil {
-		// Update history status to failed
-		a.config.UpdateHistoryStatus(config.StatusFailed)
+		a.active.SetError(err.Error())
 	} else {
-		// Update history status to cancelled
-		a.config.UpdateHistoryStatus(config.StatusCancelled)
+		a.active.SetError("")
 	}
 
-	// Clear active job
-	a.config.ActiveJob = nil
-
-	// Save config
-	saveErr := a.config.Save()
-	if saveErr != nil {
-		return saveErr
+	// A failed reschedule of an OS timer drops the job
+	if a.config.ActiveJob == nil {
+		a.screen = ScreenHome
+		a.home.Reset()
 	}
+}
 
+// cancelShutdown cancels the current shutdown timer
+func (a *App) cancelShutdown() error {
+	err := a.control.Cancel()
+	if a.daemon || errors.Is(err, config.ErrModified) {
+		a.reload()
+	}
+	if errors.Is(err, scheduler.ErrNoActiveJob) {
+		return nil
+	}
 	return err
 }
 
@@ -466,7 +558,7 @@ func (a *App) Cleanup() {
 	if a.config.ActiveJob != nil {
 		// Check if job has expired
 		now := time.Now()
-		if now.After(a.config.ActiveJob.EndTime) {
+		if a.config.ActiveJob.Expired(now) {
 			// Job has expired, clear it
 			a.config.ActiveJob = nil
 			_ = a.config.Save()
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kaganyuksek/gotosleep/internal/notify"
+)
+
+// notificationMsg carries a button clicked on a warning notification
+type notificationMsg notify.Action
+
+// waitForNotification returns a command that waits for the next notification button
+func waitForNotification(ch <-chan notify.Action) tea.Cmd {
+	return func() tea.Msg {
+		return notificationMsg(<-ch)
+	}
+}
+
+// warn sends warning notifications while no daemon is timing the job,
+// the daemon sends its own once it runs
+func (a *App) warn() {
+	if a.warner == nil || a.daemon {
+		return
+	}
+	if err := a.warner.Check(a.config.ActiveJob, a.config.Settings, time.Now()); err != nil {
+		a.err = err.Error()
+	}
+}
+
+// handleNotification routes a warning notification button to the active job
+func (a *App) handleNotification(action notify.Action) tea.Cmd {
+	switch action {
+	case notify.ActionSnooze:
+		a.extendShutdown(time.Duration(a.config.Settings.SnoozeMinutes) * time.Minute)
+	case notify.ActionCancel:
+		if err := a.cancelShutdown(); err != nil {
+			a.err = err.Error()
+		}
+		if a.screen == ScreenActive {
+			a.screen = ScreenHome
+			a.home.Reset()
+		}
+	}
+	return waitForNotification(a.notifications)
+}
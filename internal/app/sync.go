@@ -0,0 +1,98 @@
+package app
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/daemon"
+	"github.com/kaganyuksek/gotosleep/internal/scheduler"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+)
+
+// syncInterval is how often the app checks the daemon or the state file for
+// external changes
+const syncInterval = 2 * time.Second
+
+// probeInterval is how often the app looks for a daemon started after it
+const probeInterval = 10 * time.Second
+
+// syncMsg triggers a check of the daemon state
+type syncMsg struct{}
+
+// syncTick returns a command that sends a syncMsg after syncInterval
+func syncTick() tea.Cmd {
+	return tea.Tick(syncInterval, func(time.Time) tea.Msg {
+		return syncMsg{}
+	})
+}
+
+// sync mirrors jobs started, cancelled or fired outside this app
+func (a *App) sync() tea.Cmd {
+	if !a.daemon && time.Since(a.probed) >= probeInterval {
+		// A daemon started after the app takes over from then on
+		a.probed = time.Now()
+		if client, err := daemon.Dial(); err == nil {
+			a.control, a.daemon = client, true
+		}
+	}
+
+	job := a.config.ActiveJob
+	var status scheduler.Status
+	if a.daemon {
+		var err error
+		if status, err = a.control.Status(); err != nil {
+			// The daemon went away, fall back to the OS shutdown command
+			a.control, a.daemon = scheduler.New(a.config, shutdown.NewExecutor()), false
+			a.probed = time.Now()
+			a.warn()
+			return syncTick()
+		}
+		if status.Job != nil {
+			a.active.SetTriggerDetail(status.Job.TriggerDetail)
+		}
+	} else {
+		// Without a daemon other instances only leave their changes in the
+		// files, which are read again once they were written
+		modified, err := a.config.Modified()
+		if err != nil {
+			a.err = err.Error()
+		}
+		if modified {
+			a.reload()
+		}
+		a.warn()
+		status = scheduler.NewStatus(a.config, time.Now())
+	}
+
+	changed := (status.Job == nil) != (job == nil) ||
+		(status.Job != nil && (!status.Job.EndTime.Equal(job.EndTime) || status.Job.Waiting != job.Waiting))
+	if !changed {
+		return syncTick()
+	}
+	if a.daemon {
+		a.reload()
+	}
+
+	// Leave the countdown once the job was cancelled or fired elsewhere
+	if a.config.ActiveJob == nil && a.screen == ScreenActive {
+		a.screen = ScreenHome
+		a.home.Reset()
+	}
+	return syncTick()
+}
+
+// reload replaces the in-memory config with the state written by the daemon
+// or another gts instance
+func (a *App) reload() {
+	cfg, err := config.Load()
+	if err != nil {
+		a.err = err.Error()
+		return
+	}
+
+	*a.config = *cfg
+	a.active.Refresh(a.config)
+	a.history.Refresh(a.config)
+	a.schedules.Refresh(a.config)
+}
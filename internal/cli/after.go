@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/daemon"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+	"github.com/kaganyuksek/gotosleep/internal/trigger"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// errNoCondition is returned when "gts after" is given no condition to wait for
+var errNoCondition = errors.New("after needs a condition, e.g. --idle 10m")
+
+// errManyConditions is returned when "gts after" is given more than one condition
+var errManyConditions = errors.New("after takes a single condition")
+
+// runAfter handles "gts after <condition> [--grace D] [--action A] [--dry-run] [--yes]"
+func (c *CLI) runAfter(args []string) int {
+	fs := c.newFlagSet("after")
+	idle := fs.String("idle", "", "wait until the session has been idle this long")
+	pid := fs.Int("pid", 0, "wait until the process with this PID exits")
+	name := fs.String("name", "", "wait until every process with this name exits")
+	network := fs.String("net", "", "wait until traffic on this interface, or all, is quiet")
+	load := fs.String("load", "", "wait until the load average stays below this level")
+	cpu := fs.String("cpu", "", "wait until CPU usage stays below this percentage")
+	media := fs.String("media", "", "wait until playback stops (stop) or the current track ends (track)")
+	player := fs.String("player", "", "media player to watch, e.g. vlc")
+	battery := fs.String("battery", "", "wait until the battery drops below this percentage")
+	keepOnAC := fs.Bool("keep-on-ac", false, "wait again instead of cancelling when AC returns")
+	below := fs.String("below", "", "level a quiet condition stays under")
+	quietFor := fs.String("for", "", "how long a quiet condition must hold")
+	grace := fs.String("grace", "", "countdown once the condition is met")
+	actionName := fs.String("action", "poweroff", "power action to perform")
+	dryRun := fs.Bool("dry-run", false, "simulate without performing the action")
+	yes := fs.Bool("yes", false, "skip the confirmation prompt")
+	fs.BoolVar(yes, "y", false, "skip the confirmation prompt")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 0 {
+		fmt.Fprintf(c.Stderr, "Error: after takes no arguments\n\n%s", usage)
+		return ExitUsage
+	}
+
+	// Exactly one condition decides when the countdown starts
+	conditions := 0
+	for _, flagName := range []string{"idle", "pid", "name", "net", "load", "cpu", "media", "battery"} {
+		if isFlagSet(fs, flagName) {
+			conditions++
+		}
+	}
+	if conditions > 1 {
+		fmt.Fprintf(c.Stderr, "Error: %v\n\n%s", errManyConditions, usage)
+		return ExitUsage
+	}
+
+	var t config.Trigger
+	switch {
+	case *idle != "":
+		minutes, err := utils.ParseDuration(*idle)
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+			return ExitInvalidDuration
+		}
+		t = config.Trigger{Kind: config.TriggerIdle, IdleSeconds: minutes * 60}
+	case isFlagSet(fs, "pid"):
+		if *pid <= 0 {
+			fmt.Fprintf(c.Stderr, "Error: invalid pid: %d\n", *pid)
+			return ExitUsage
+		}
+		t = config.Trigger{Kind: config.TriggerProcess, PID: *pid}
+	case *name != "":
+		t = config.Trigger{Kind: config.TriggerProcess, Process: *name}
+	case *network != "":
+		t = config.Trigger{Kind: config.TriggerNetwork, RateBytes: config.DefaultNetworkQuietRate}
+		if *network != "all" {
+			t.Interface = *network
+		}
+		if *below != "" {
+			if t.RateBytes, err = utils.ParseRate(*below); err != nil {
+				fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+				return ExitUsage
+			}
+		}
+	case *load != "":
+		level, err := strconv.ParseFloat(*load, 64)
+		if err != nil || level <= 0 {
+			fmt.Fprintf(c.Stderr, "Error: invalid load: %s\n", *load)
+			return ExitUsage
+		}
+		t = config.Trigger{Kind: config.TriggerLoad, LoadBelow: level}
+	case *cpu != "":
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(*cpu, "%"), 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			fmt.Fprintf(c.Stderr, "Error: invalid CPU percentage: %s\n", *cpu)
+			return ExitUsage
+		}
+		t = config.Trigger{Kind: config.TriggerCPU, CPUBelow: percent}
+	case *media != "":
+		if *media != config.MediaStop && *media != config.MediaTrack {
+			fmt.Fprintf(c.Stderr, "Error: invalid media mode: %s (use stop or track)\n", *media)
+			return ExitUsage
+		}
+		t = config.Trigger{Kind: config.TriggerMedia, Media: *media, Player: *player}
+	case *battery != "":
+		percent, err := strconv.Atoi(strings.TrimSuffix(*battery, "%"))
+		if err != nil || percent <= 0 || percent > 100 {
+			fmt.Fprintf(c.Stderr, "Error: invalid battery percentage: %s\n", *battery)
+			return ExitUsage
+		}
+		t = config.Trigger{Kind: config.TriggerBattery, BatteryBelow: percent, KeepOnAC: *keepOnAC}
+	default:
+		fmt.Fprintf(c.Stderr, "Error: %v\n\n%s", errNoCondition, usage)
+		return ExitUsage
+	}
+
+	// Quiet conditions must hold for a window before they count as met
+	switch t.Kind {
+	case config.TriggerNetwork, config.TriggerLoad, config.TriggerCPU:
+		minutes := config.DefaultQuietMinutes
+		if *quietFor != "" {
+			if minutes, err = utils.ParseDuration(*quietFor); err != nil {
+				fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+				return ExitInvalidDuration
+			}
+		}
+		t.QuietSeconds = minutes * 60
+	default:
+		if *quietFor != "" {
+			fmt.Fprintf(c.Stderr, "Error: --for only applies to --net, --load and --cpu\n\n%s", usage)
+			return ExitUsage
+		}
+	}
+	if *keepOnAC && t.Kind != config.TriggerBattery {
+		fmt.Fprintf(c.Stderr, "Error: --keep-on-ac only applies to --battery\n\n%s", usage)
+		return ExitUsage
+	}
+	if *player != "" && t.Kind != config.TriggerMedia {
+		fmt.Fprintf(c.Stderr, "Error: --player only applies to --media\n\n%s", usage)
+		return ExitUsage
+	}
+	if *below != "" && t.Kind != config.TriggerNetwork {
+		fmt.Fprintf(c.Stderr, "Error: --below only applies to --net\n\n%s", usage)
+		return ExitUsage
+	}
+
+	action, err := shutdown.ParseAction(*actionName)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitUsage
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+
+	graceMinutes := cfg.Settings.GraceMinutes
+	if *grace != "" {
+		if graceMinutes, err = utils.ParseDuration(*grace); err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+			return ExitInvalidDuration
+		}
+	}
+
+	// Settings provide the default, an explicit flag wins
+	dry := cfg.Settings.DryRunDefault
+	if isFlagSet(fs, "dry-run") {
+		dry = *dryRun
+	}
+
+	if cfg.Settings.Confirm && !*yes {
+		question := fmt.Sprintf("Schedule %s %s after %s?", action, utils.FormatDuration(graceMinutes), trigger.Describe(t))
+		if dry {
+			question = fmt.Sprintf("Schedule a dry-run %s %s after %s?", action, utils.FormatDuration(graceMinutes), trigger.Describe(t))
+		}
+		if !c.confirm(question) {
+			fmt.Fprintln(c.Stderr, "Aborted")
+			return ExitError
+		}
+	}
+
+	ctl := daemon.Connect(cfg)
+	if err := ctl.Arm(t, time.Duration(graceMinutes)*time.Minute, action, dry); err != nil {
+		return c.exitCode(err)
+	}
+
+	fmt.Fprintf(c.Stdout, "%s scheduled %s after %s\n", action, utils.FormatDuration(graceMinutes), trigger.Describe(t))
+	if dry {
+		fmt.Fprintln(c.Stdout, "Dry run: the action will not be executed")
+	}
+	c.printHooks(cfg.Settings, config.OwnerDaemon, dry)
+	return ExitOK
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/daemon"
+)
+
+// runCancel handles "gts cancel"
+func (c *CLI) runCancel(args []string) int {
+	fs := c.newFlagSet("cancel")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 0 {
+		fmt.Fprintf(c.Stderr, "Error: cancel takes no arguments\n\n%s", usage)
+		return ExitUsage
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+
+	if err := daemon.Connect(cfg).Cancel(); err != nil {
+		return c.exitCode(err)
+	}
+
+	fmt.Fprintln(c.Stdout, "Shutdown cancelled")
+	return ExitOK
+}
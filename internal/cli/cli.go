@@ -0,0 +1,265 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/scheduler"
+)
+
+// Exit codes returned by Run
+const (
+	ExitOK              = 0
+	ExitError           = 1
+	ExitUsage           = 2
+	ExitInvalidDuration = 3
+	ExitExecutorFailed  = 4
+	ExitNoActiveJob     = 5
+	ExitForbidden       = 6
+)
+
+const usage = `Usage:
+  gts                            Open the interactive timer
+  gts start <when> [flags]       Schedule a power action
+  gts after <condition> [flags]  Schedule a power action once a condition is met
+  gts run [flags] -- <command>   Run a command, then schedule a power action
+  gts cancel                     Cancel the scheduled shutdown
+  gts extend <delta>             Push the scheduled shutdown back
+  gts shorten <delta>            Bring the scheduled shutdown forward
+  gts status [--json]            Show the scheduled shutdown
+  gts history [flags]            Show past shutdown timers
+  gts schedule [list]            Show recurring schedules
+  gts schedule add <days> <time> Add a recurring schedule, e.g. mon-fri 23:30
+  gts schedule add --cron E      Add a schedule from a cron expression
+  gts schedule next <id|E>       Show the next 5 runs of a schedule or expression
+  gts schedule remove|enable|disable <id>
+  gts schedule install           Run schedules from systemd user timers
+  gts schedule uninstall         Remove the systemd user timers
+  gts daemon                     Run the background timer daemon
+
+Global flags, before the command:
+  --config F     config file (default from GTS_CONFIG, else gts/config.toml
+                 in the user config directory)
+  --state-dir D  directory of the state and history (default from
+                 GTS_STATE_DIR, else $XDG_STATE_HOME/gts on Linux)
+
+Start flags:
+  --action A  Power action: poweroff, reboot, suspend, hibernate, logout, lock
+  --dry-run   Simulate without scheduling a real shutdown
+  --yes, -y   Skip the confirmation prompt
+
+After conditions (need gts daemon):
+  --idle D      the session has been idle for D
+  --pid N       the process with PID N has exited (Linux)
+  --name C      every process named C has exited (Linux)
+  --net I       traffic on interface I, or all, is quiet (Linux)
+  --load L      the one-minute load average is below L (Linux)
+  --cpu P       CPU usage is below P percent (Linux)
+  --media M     playback stops (stop) or the current track ends (track)
+  --battery P   the battery drops below P percent on battery power (Linux)
+After flags:
+  --grace D     countdown once the condition is met (default 5m)
+  --below R     quiet level, e.g. 50KB/s for --net (default 10KB/s)
+  --for D       how long --net, --load or --cpu must hold (default 5m)
+  --player P    media player for --media, e.g. vlc (default any)
+  --keep-on-ac  --battery waits again when AC returns instead of cancelling
+  and the start flags above
+
+Run flags:
+  --on-success  only act when the command exits with 0
+  --on-failure  only act when the command fails
+  --grace D, and the start flags above
+
+History flags:
+  --status S  only these statuses, e.g. cancelled,failed
+  --action A  only these actions, e.g. reboot,suspend
+  --os O      only entries recorded on this OS, e.g. linux
+  --since T   created at or after T: today, yesterday, 2006-01-02,
+              "2006-01-02 15:04", or how long ago, e.g. 7d, 12h
+  --until T   created before T, a date includes the whole day
+  --limit N   at most the N newest matches
+  --json      print the entries as JSON
+
+Schedule flags:
+  --cron E    5-field cron expression or macro, e.g. "30 23 * * 1-5", @daily
+  --tz Z      time zone, e.g. Europe/Istanbul (default local)
+  --lead D    countdown before the time (default 15m)
+  --name N    label of the schedule
+  --count N   runs shown by next (default 5)
+  --action A, --dry-run
+
+Days:      daily, weekdays, weekends, mon,wed,fri, mon-fri
+Durations: 90, 90m, 1h30m, 2h, 00:45, 1:20
+Deltas:    15m, +1h, -5m
+Times:     @23:30, at 01:15, tomorrow 07:00, @11pm
+
+Exit codes:
+  0  success
+  1  general error
+  2  invalid usage
+  3  invalid duration or time
+  4  shutdown command failed
+  5  no active shutdown job
+  6  time falls in a forbidden window
+`
+
+// CLI runs gts subcommands without the interactive UI
+type CLI struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// New creates a CLI bound to the process standard streams
+func New() *CLI {
+	return &CLI{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// Run executes the subcommand in args and returns the process exit code
+func (c *CLI) Run(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(c.Stderr, usage)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "start":
+		return c.runStart(args[1:])
+	case "cancel":
+		return c.runCancel(args[1:])
+	case "after":
+		return c.runAfter(args[1:])
+	case "run":
+		return c.runRun(args[1:])
+	case "extend", "shorten":
+		return c.runExtend(args[0], args[1:])
+	case "status":
+		return c.runStatus(args[1:])
+	case "history":
+		return c.runHistory(args[1:])
+	case "schedule":
+		return c.runSchedule(args[1:])
+	case "daemon":
+		return c.runDaemon(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(c.Stdout, usage)
+		return ExitOK
+	}
+
+	fmt.Fprintf(c.Stderr, "Unknown command: %s\n\n%s", args[0], usage)
+	return ExitUsage
+}
+
+// ApplyGlobalFlags applies the --config and --state-dir flags at the start
+// of args and returns the arguments after them
+func ApplyGlobalFlags(args []string) ([]string, error) {
+	var configFile, stateDir string
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		name, value, inline := strings.Cut(args[0], "=")
+		var target *string
+		switch name {
+		case "--config":
+			target = &configFile
+		case "--state-dir":
+			target = &stateDir
+		default:
+			// Flags of the subcommands, e.g. "gts --help"
+			config.Override(configFile, stateDir)
+			return args, nil
+		}
+
+		if !inline {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("flag needs an argument: %s", name)
+			}
+			value, args = args[1], args[1:]
+		}
+		if value == "" {
+			return nil, fmt.Errorf("flag needs an argument: %s", name)
+		}
+		*target = value
+		args = args[1:]
+	}
+	config.Override(configFile, stateDir)
+	return args, nil
+}
+
+// newFlagSet creates a flag set that reports errors instead of exiting
+func (c *CLI) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.Stderr)
+	fs.Usage = func() {
+		fmt.Fprint(c.Stderr, usage)
+	}
+	return fs
+}
+
+// parseArgs parses flags that may appear before or after positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		// Everything after a "--" terminator is positional
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// isFlagSet reports whether the named flag was given explicitly
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// confirm asks a yes/no question on stdin and defaults to no
+func (c *CLI) confirm(question string) bool {
+	fmt.Fprintf(c.Stdout, "%s [y/N] ", question)
+	line, err := bufio.NewReader(c.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(c.Stdout)
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+// exitCode maps a scheduler error to a process exit code
+func (c *CLI) exitCode(err error) int {
+	fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+
+	var execErr *scheduler.ExecutorError
+	var windowErr *scheduler.WindowError
+	switch {
+	case errors.As(err, &execErr):
+		return ExitExecutorFailed
+	case errors.As(err, &windowErr):
+		return ExitForbidden
+	case errors.Is(err, scheduler.ErrNoActiveJob):
+		return ExitNoActiveJob
+	}
+	return ExitError
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/kaganyuksek/gotosleep/internal/daemon"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+)
+
+// runDaemon handles "gts daemon"
+func (c *CLI) runDaemon(args []string) int {
+	fs := c.newFlagSet("daemon")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 0 {
+		fmt.Fprintf(c.Stderr, "Error: daemon takes no arguments\n\n%s", usage)
+		return ExitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := log.New(c.Stderr, "gts daemon: ", log.LstdFlags)
+	if err := daemon.New(shutdown.NewExecutor(), logger).Run(ctx); err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	return ExitOK
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/daemon"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// runExtend handles "gts extend" and "gts shorten"; the arguments are not
+// run through the flag parser because "-5m" would look like a flag
+func (c *CLI) runExtend(name string, args []string) int {
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		fmt.Fprintf(c.Stderr, "Error: %s needs a duration\n\n%s", name, usage)
+		return ExitUsage
+	}
+
+	delta, err := utils.ParseAdjustment(strings.Join(args, " "))
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitInvalidDuration
+	}
+	if name == "shorten" {
+		delta = -delta.Abs()
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+
+	ctl := daemon.Connect(cfg)
+	if err := ctl.Extend(delta); err != nil {
+		return c.exitCode(err)
+	}
+
+	status, err := ctl.Status()
+	if err != nil {
+		return c.exitCode(err)
+	}
+	if status.Job == nil {
+		fmt.Fprintln(c.Stderr, "Error: scheduled job not found")
+		return ExitError
+	}
+
+	job := status.Job
+	if job.Waiting {
+		fmt.Fprintf(c.Stdout, "%s countdown changed to %s once its trigger is met\n",
+			job.Action, utils.FormatDuration(job.DurationSec/60))
+		return ExitOK
+	}
+	fmt.Fprintf(c.Stdout, "%s rescheduled for %s (in %s)\n",
+		job.Action,
+		job.EndTime.Format("2006-01-02 15:04:05"),
+		utils.FormatDuration((job.RemainingSeconds+59)/60))
+	return ExitOK
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// historyStatuses lists the statuses --status accepts
+var historyStatuses = []string{
+	config.StatusOK,
+	config.StatusCancelled,
+	config.StatusFailed,
+	config.StatusDryRun,
+	config.StatusMissed,
+	config.StatusAborted,
+	config.StatusSkipped,
+}
+
+// runHistory handles "gts history [--limit N] [--status S] [--action A] [--os O] [--since T] [--until T] [--json]"
+func (c *CLI) runHistory(args []string) int {
+	fs := c.newFlagSet("history")
+	limit := fs.Int("limit", 0, "maximum number of entries to show")
+	status := fs.String("status", "", "comma-separated statuses to show")
+	action := fs.String("action", "", "comma-separated actions to show")
+	osName := fs.String("os", "", "only entries recorded on this OS")
+	since := fs.String("since", "", "only entries created at or after this time")
+	until := fs.String("until", "", "only entries created before this time")
+	asJSON := fs.Bool("json", false, "print the entries as JSON")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 0 {
+		fmt.Fprintf(c.Stderr, "Error: history takes no arguments\n\n%s", usage)
+		return ExitUsage
+	}
+
+	q := config.HistoryQuery{Limit: *limit, OS: *osName}
+	for _, s := range splitList(*status) {
+		if !isHistoryStatus(s) {
+			fmt.Fprintf(c.Stderr, "Error: unknown status %q, use one of %s\n", s, strings.Join(historyStatuses, ", "))
+			return ExitUsage
+		}
+		q.Statuses = append(q.Statuses, s)
+	}
+	for _, name := range splitList(*action) {
+		a, err := shutdown.ParseAction(name)
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+			return ExitUsage
+		}
+		q.Actions = append(q.Actions, string(a))
+	}
+
+	now := time.Now()
+	if *since != "" {
+		if q.Since, err = parseHistoryTime(*since, now, false); err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+			return ExitInvalidDuration
+		}
+	}
+	if *until != "" {
+		if q.Until, err = parseHistoryTime(*until, now, true); err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+			return ExitInvalidDuration
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	entries := cfg.QueryHistory(q)
+
+	if *asJSON {
+		enc := json.NewEncoder(c.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+			return ExitError
+		}
+		return ExitOK
+	}
+
+	if len(entries) == 0 {
+		if len(cfg.History) == 0 {
+			fmt.Fprintln(c.Stdout, "No history yet")
+		} else {
+			fmt.Fprintln(c.Stdout, "No matching history")
+		}
+		return ExitOK
+	}
+
+	w := tabwriter.NewWriter(c.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CREATED\tDURATION\tSCHEDULED\tACTION\tSTATUS\tCOMMAND")
+	for _, h := range entries {
+		scheduled := "-"
+		if !h.ScheduledFor.IsZero() {
+			scheduled = h.ScheduledFor.Format("15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			h.CreatedAt.Format("2006-01-02 15:04"),
+			utils.FormatDuration(h.DurationSeconds/60),
+			scheduled,
+			shutdown.ActionOrDefault(h.Action),
+			h.Status,
+			h.Command,
+		)
+	}
+	w.Flush()
+	return ExitOK
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// isHistoryStatus reports whether s is a status history entries can have
+func isHistoryStatus(s string) bool {
+	for _, status := range historyStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHistoryTime parses a --since or --until value: today, yesterday, a
+// date, a date and time, or how long ago such as 7d or 12h. A date given
+// to --until includes that whole day.
+func parseHistoryTime(value string, now time.Time, until bool) (time.Time, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	day, isDay := time.Time{}, true
+	switch value {
+	case "today":
+		day = today
+	case "yesterday":
+		day = today.AddDate(0, 0, -1)
+	default:
+		var err error
+		day, err = time.ParseInLocation("2006-01-02", value, now.Location())
+		isDay = err == nil
+	}
+	if isDay {
+		if until {
+			return day.AddDate(0, 0, 1), nil
+		}
+		return day, nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, strings.ToUpper(value), now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if minutes, err := utils.ParseDuration(value); err == nil {
+		return now.Add(-time.Duration(minutes) * time.Minute), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use e.g. today, 2006-01-02, \"2006-01-02 15:04\" or 7d", value)
+}
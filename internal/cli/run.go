@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/daemon"
+	"github.com/kaganyuksek/gotosleep/internal/scheduler"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// runRun handles "gts run [flags] [--] <command> [args...]". The command runs
+// in the foreground with the terminal's streams, and the power action is
+// scheduled once it exits.
+func (c *CLI) runRun(args []string) int {
+	fs := c.newFlagSet("run")
+	onSuccess := fs.Bool("on-success", false, "only act when the command exits with 0")
+	onFailure := fs.Bool("on-failure", false, "only act when the command fails")
+	grace := fs.String("grace", "", "countdown once the command exits")
+	actionName := fs.String("action", "poweroff", "power action to perform")
+	dryRun := fs.Bool("dry-run", false, "simulate without performing the action")
+	yes := fs.Bool("yes", false, "skip the confirmation prompt")
+	fs.BoolVar(yes, "y", false, "skip the confirmation prompt")
+
+	// Flags end at the command, its own flags must not be parsed
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	command := fs.Args()
+	if len(command) == 0 {
+		fmt.Fprintf(c.Stderr, "Error: run expects a command\n\n%s", usage)
+		return ExitUsage
+	}
+	if *onSuccess && *onFailure {
+		fmt.Fprintf(c.Stderr, "Error: --on-success and --on-failure cannot be combined\n\n%s", usage)
+		return ExitUsage
+	}
+
+	when := config.RunWhenAlways
+	switch {
+	case *onSuccess:
+		when = config.RunWhenSuccess
+	case *onFailure:
+		when = config.RunWhenFailure
+	}
+
+	action, err := shutdown.ParseAction(*actionName)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitUsage
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+
+	graceMinutes := cfg.Settings.GraceMinutes
+	if *grace != "" {
+		if graceMinutes, err = utils.ParseDuration(*grace); err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+			return ExitInvalidDuration
+		}
+	}
+
+	// Settings provide the default, an explicit flag wins
+	dry := cfg.Settings.DryRunDefault
+	if isFlagSet(fs, "dry-run") {
+		dry = *dryRun
+	}
+
+	// Refuse before the command runs rather than once it exits
+	if !dry && len(cfg.Settings.Hooks) > 0 {
+		if _, err := daemon.Dial(); err != nil {
+			return c.exitCode(scheduler.ErrHooksNeedDaemon)
+		}
+	}
+
+	commandLine := strings.Join(command, " ")
+	if cfg.Settings.Confirm && !*yes {
+		question := fmt.Sprintf("Run %s and %s %s after it %s?", commandLine, action, utils.FormatDuration(graceMinutes), describeRunWhen(when))
+		if dry {
+			question = fmt.Sprintf("Run %s and schedule a dry-run %s %s after it %s?", commandLine, action, utils.FormatDuration(graceMinutes), describeRunWhen(when))
+		}
+		if !c.confirm(question) {
+			fmt.Fprintln(c.Stderr, "Aborted")
+			return ExitError
+		}
+	}
+
+	run, interrupted, err := c.execute(command)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	run.When = when
+
+	elapsed := (time.Duration(run.DurationMs) * time.Millisecond).Round(time.Second)
+	if run.ExitCode < 0 {
+		fmt.Fprintf(c.Stderr, "%s was killed by a signal after %s\n", command[0], elapsed)
+	} else {
+		fmt.Fprintf(c.Stderr, "%s exited with code %d after %s\n", command[0], run.ExitCode, elapsed)
+	}
+
+	// The command may have run for hours, start again from the current state
+	if cfg, err = config.Load(); err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+
+	if interrupted || !runMatches(when, run.ExitCode) {
+		reason := "the command failed"
+		switch {
+		case interrupted:
+			reason = "gts was interrupted"
+		case run.ExitCode == 0:
+			reason = "the command succeeded"
+		}
+		fmt.Fprintf(c.Stdout, "Not scheduling %s: %s\n", action, reason)
+
+		err := config.Update(func(cfg *config.Config) error {
+			cfg.AddHistory(config.History{
+				ID:        utils.GenerateID(),
+				CreatedAt: time.Now(),
+				Status:    config.StatusSkipped,
+				OS:        shutdown.NewExecutor().GetOS(),
+				Action:    string(action),
+				Run:       &run,
+			})
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+			return ExitError
+		}
+		if interrupted {
+			return ExitError
+		}
+		return ExitOK
+	}
+
+	endTime := time.Now().Add(time.Duration(graceMinutes) * time.Minute)
+	if err := c.checkWindows(cfg.Settings.ForbiddenWindows, endTime); err != nil {
+		return c.exitCode(err)
+	}
+
+	ctl := daemon.Connect(cfg)
+	if err := ctl.StartAt(endTime, action, dry); err != nil {
+		return c.exitCode(err)
+	}
+
+	status, err := ctl.Status()
+	if err != nil {
+		return c.exitCode(err)
+	}
+	if status.Job == nil {
+		fmt.Fprintln(c.Stderr, "Error: scheduled job not found")
+		return ExitError
+	}
+
+	// Attach the run to the history entry of the new job, which the daemon
+	// may have written, so reload once more
+	job := status.Job
+	err = config.Update(func(cfg *config.Config) error {
+		cfg.RecordRun(job.StartTime, run)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Warning: failed to record the command in history: %v\n", err)
+	}
+
+	fmt.Fprintf(c.Stdout, "%s scheduled for %s (in %s)\n",
+		action,
+		job.EndTime.Format("2006-01-02 15:04:05"),
+		utils.FormatDuration(job.DurationSec/60))
+	if dry {
+		fmt.Fprintf(c.Stdout, "Dry run: %s was not executed\n", job.Command)
+	}
+	c.printHooks(cfg.Settings, job.Owner, dry)
+	return ExitOK
+}
+
+// execute runs command with the CLI streams and waits for it. Interrupts
+// reach the command through the terminal, gts keeps running to record the
+// result but reports that it was interrupted; a terminate signal sent to gts
+// alone is passed on to the command.
+func (c *CLI) execute(command []string) (config.RunResult, bool, error) {
+	run := config.RunResult{Command: strings.Join(command, " ")}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = c.Stdout
+	cmd.Stderr = c.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	run.StartedAt = time.Now()
+	if err := cmd.Start(); err != nil {
+		return run, false, fmt.Errorf("failed to run %s: %w", command[0], err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	interrupted := false
+	for {
+		select {
+		case sig := <-signals:
+			interrupted = true
+			if sig != os.Interrupt {
+				_ = cmd.Process.Signal(sig)
+			}
+		case err := <-done:
+			run.DurationMs = time.Since(run.StartedAt).Milliseconds()
+			var exitErr *exec.ExitError
+			if err != nil && !errors.As(err, &exitErr) {
+				return run, interrupted, fmt.Errorf("failed to wait for %s: %w", command[0], err)
+			}
+			run.ExitCode = cmd.ProcessState.ExitCode()
+			return run, interrupted, nil
+		}
+	}
+}
+
+// runMatches reports whether a command that exited with code satisfies when
+func runMatches(when string, code int) bool {
+	switch when {
+	case config.RunWhenSuccess:
+		return code == 0
+	case config.RunWhenFailure:
+		return code != 0
+	default:
+		return true
+	}
+}
+
+// describeRunWhen completes "after it ..." for when
+func describeRunWhen(when string) string {
+	switch when {
+	case config.RunWhenSuccess:
+		return "succeeds"
+	case config.RunWhenFailure:
+		return "fails"
+	default:
+		return "exits"
+	}
+}
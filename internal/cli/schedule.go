@@ -0,0 +1,435 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/daemon"
+	"github.com/kaganyuksek/gotosleep/internal/schedule"
+	"github.com/kaganyuksek/gotosleep/internal/scheduler"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// previewRuns is how many upcoming runs are shown for a schedule
+const previewRuns = 5
+
+// runSchedule handles "gts schedule <subcommand>"
+func (c *CLI) runSchedule(args []string) int {
+	if len(args) == 0 {
+		return c.runScheduleList(nil)
+	}
+
+	switch args[0] {
+	case "list":
+		return c.runScheduleList(args[1:])
+	case "add":
+		return c.runScheduleAdd(args[1:])
+	case "remove", "enable", "disable":
+		return c.runScheduleEdit(args[0], args[1:])
+	case "next":
+		return c.runScheduleNext(args[1:])
+	case "run":
+		return c.runScheduleRun(args[1:])
+	case "install":
+		return c.runScheduleInstall(args[1:])
+	case "uninstall":
+		return c.runScheduleUninstall(args[1:])
+	}
+
+	fmt.Fprintf(c.Stderr, "Unknown schedule command: %s\n\n%s", args[0], usage)
+	return ExitUsage
+}
+
+// runScheduleList handles "gts schedule list"
+func (c *CLI) runScheduleList(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintf(c.Stderr, "Error: schedule list takes no arguments\n\n%s", usage)
+		return ExitUsage
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	if len(cfg.Schedules) == 0 {
+		fmt.Fprintln(c.Stdout, "No schedules yet")
+		return ExitOK
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(c.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tWHEN\tACTION\tLEAD\tNEXT\tNAME")
+	for _, s := range cfg.Schedules {
+		next := "disabled"
+		if s.Enabled {
+			next = "-"
+			if at, err := schedule.Pending(s, now); err == nil {
+				next = at.Format("Mon 2006-01-02 15:04 MST")
+			}
+		}
+		action := string(shutdown.ActionOrDefault(s.Action))
+		if s.DryRun {
+			action += " (dry run)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.ID,
+			schedule.Describe(s),
+			action,
+			utils.FormatDuration(int(schedule.Lead(s).Minutes())),
+			next,
+			s.Name,
+		)
+	}
+	w.Flush()
+	return ExitOK
+}
+
+// runScheduleAdd handles "gts schedule add <days> <time>|--cron E [--tz Z] [--action A] [--lead D] [--name N] [--dry-run]"
+func (c *CLI) runScheduleAdd(args []string) int {
+	fs := c.newFlagSet("schedule add")
+	cron := fs.String("cron", "", "cron expression instead of days and time")
+	timezone := fs.String("tz", "", "time zone of the schedule, e.g. Europe/Istanbul")
+	actionName := fs.String("action", "poweroff", "power action to perform")
+	lead := fs.String("lead", "", "how long before the time the countdown starts")
+	name := fs.String("name", "", "label of the schedule")
+	dryRun := fs.Bool("dry-run", false, "simulate without performing the action")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+
+	s := config.Schedule{
+		Name:     *name,
+		Cron:     strings.TrimSpace(*cron),
+		Timezone: *timezone,
+		DryRun:   *dryRun,
+		Enabled:  true,
+	}
+	switch {
+	case s.Cron != "" && len(positional) != 0:
+		fmt.Fprintf(c.Stderr, "Error: schedule add takes either days and a time or --cron\n\n%s", usage)
+		return ExitUsage
+	case s.Cron == "" && len(positional) != 2:
+		fmt.Fprintf(c.Stderr, "Error: schedule add expects days and a time, e.g. mon-fri 23:30\n\n%s", usage)
+		return ExitUsage
+	case s.Cron == "":
+		if s.Days, err = schedule.ParseDays(positional[0]); err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+			return ExitUsage
+		}
+		hour, minute, err := utils.ParseClock(positional[1])
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+			return ExitInvalidDuration
+		}
+		s.Time = fmt.Sprintf("%02d:%02d", hour, minute)
+	}
+	if err := schedule.Validate(s); err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitInvalidDuration
+	}
+
+	action, err := shutdown.ParseAction(*actionName)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitUsage
+	}
+	s.Action = string(action)
+
+	s.LeadMinutes = config.DefaultScheduleLeadMinutes
+	if *lead != "" {
+		if s.LeadMinutes, err = utils.ParseDuration(*lead); err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+			return ExitInvalidDuration
+		}
+	}
+
+	err = config.Update(func(cfg *config.Config) error {
+		s.ID = cfg.AddSchedule(s)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+
+	fmt.Fprintf(c.Stdout, "Added schedule %s: %s %s, countdown starts %s before\n",
+		s.ID, action, schedule.Describe(s), utils.FormatDuration(s.LeadMinutes))
+	c.printRuns(s)
+	c.printScheduleRunner()
+	return ExitOK
+}
+
+// runScheduleNext handles "gts schedule next <id|cron expression> [--count N] [--tz Z]"
+func (c *CLI) runScheduleNext(args []string) int {
+	fs := c.newFlagSet("schedule next")
+	count := fs.Int("count", previewRuns, "number of runs to show")
+	timezone := fs.String("tz", "", "time zone of a cron expression")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) == 0 {
+		fmt.Fprintf(c.Stderr, "Error: schedule next expects a schedule ID or a cron expression\n\n%s", usage)
+		return ExitUsage
+	}
+	if *count <= 0 {
+		fmt.Fprintf(c.Stderr, "Error: invalid count: %d\n", *count)
+		return ExitUsage
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+
+	// A lone argument naming a schedule previews it, anything else is an expression
+	s := config.Schedule{Cron: strings.Join(positional, " "), Timezone: *timezone}
+	if existing := cfg.FindSchedule(s.Cron); existing != nil && len(positional) == 1 {
+		s = *existing
+	}
+	if err := schedule.Validate(s); err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitInvalidDuration
+	}
+
+	runs, err := schedule.Runs(s, time.Now(), *count)
+	for _, at := range runs {
+		fmt.Fprintln(c.Stdout, at.Format("Mon 2006-01-02 15:04 MST"))
+	}
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+// printRuns shows the next runs of a new schedule
+func (c *CLI) printRuns(s config.Schedule) {
+	runs, _ := schedule.Runs(s, time.Now(), previewRuns)
+	if len(runs) == 0 {
+		return
+	}
+	fmt.Fprintln(c.Stdout, "Next runs:")
+	for _, at := range runs {
+		fmt.Fprintf(c.Stdout, "  %s\n", at.Format("Mon 2006-01-02 15:04 MST"))
+	}
+}
+
+// runScheduleEdit handles "gts schedule remove|enable|disable <id>"
+func (c *CLI) runScheduleEdit(command string, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(c.Stderr, "Error: schedule %s expects a schedule ID\n\n%s", command, usage)
+		return ExitUsage
+	}
+
+	err := config.Update(func(cfg *config.Config) error {
+		s := cfg.FindSchedule(args[0])
+		if s == nil {
+			return fmt.Errorf("%w: %s", scheduler.ErrNoSchedule, args[0])
+		}
+
+		switch command {
+		case "remove":
+			cfg.DeleteSchedule(s.ID)
+		case "enable":
+			s.Enabled = true
+		case "disable":
+			s.Enabled = false
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+
+	verb := map[string]string{"remove": "Removed", "enable": "Enabled", "disable": "Disabled"}[command]
+	fmt.Fprintf(c.Stdout, "%s schedule %s\n", verb, args[0])
+	return ExitOK
+}
+
+// runScheduleRun handles "gts schedule run <id>", which the systemd timers
+// installed by "gts schedule install" call when a countdown may be due
+func (c *CLI) runScheduleRun(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(c.Stderr, "Error: schedule run expects a schedule ID\n\n%s", usage)
+		return ExitUsage
+	}
+
+	// A running daemon starts schedules by itself
+	if _, err := daemon.Dial(); err == nil {
+		fmt.Fprintln(c.Stdout, "gts daemon is running, it starts schedules itself")
+		return ExitOK
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	s := cfg.FindSchedule(args[0])
+	if s == nil {
+		fmt.Fprintf(c.Stderr, "Error: %v: %s\n", scheduler.ErrNoSchedule, args[0])
+		return ExitError
+	}
+	if !s.Enabled {
+		fmt.Fprintf(c.Stdout, "Schedule %s is disabled\n", s.ID)
+		return ExitOK
+	}
+
+	now := time.Now()
+	at, ok := schedule.Due(*s, now)
+	if !ok {
+		fmt.Fprintf(c.Stdout, "Schedule %s is not due\n", s.ID)
+		return ExitOK
+	}
+	// Like the daemon, never replace a job the user started
+	if job := cfg.ActiveJob; job != nil && job.EndTime.After(now) {
+		fmt.Fprintf(c.Stdout, "A job is already scheduled for %s, schedule %s skipped\n", job.EndTime.Format("2006-01-02 15:04"), s.ID)
+		return ExitOK
+	}
+
+	action := shutdown.ActionOrDefault(s.Action)
+	if err := scheduler.New(cfg, shutdown.NewExecutor()).StartSchedule(s.ID, at); err != nil {
+		return c.exitCode(err)
+	}
+	fmt.Fprintf(c.Stdout, "%s scheduled for %s by schedule %s\n", action, at.Format("2006-01-02 15:04"), s.ID)
+	return ExitOK
+}
+
+// runScheduleInstall handles "gts schedule install", which writes a systemd
+// user timer for every enabled schedule so they run without the daemon
+func (c *CLI) runScheduleInstall(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintf(c.Stderr, "Error: schedule install takes no arguments\n\n%s", usage)
+		return ExitUsage
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	path, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: failed to find gts executable: %v\n", err)
+		return ExitError
+	}
+	dir, err := schedule.UnitDir()
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+
+	written, removed, err := schedule.WriteUnits(cfg.Schedules, dir, path)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	if err := c.disableTimers(dir, removed); err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	if len(written) > 0 {
+		if err := systemctl(append([]string{"enable", "--now"}, written...)...); err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+			return ExitError
+		}
+	}
+
+	for _, timer := range written {
+		fmt.Fprintf(c.Stdout, "Installed %s\n", timer)
+	}
+	for _, timer := range removed {
+		fmt.Fprintf(c.Stdout, "Removed %s\n", timer)
+	}
+	if len(written) == 0 && len(removed) == 0 {
+		fmt.Fprintln(c.Stdout, "No enabled schedules to install")
+	}
+	return ExitOK
+}
+
+// runScheduleUninstall handles "gts schedule uninstall"
+func (c *CLI) runScheduleUninstall(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintf(c.Stderr, "Error: schedule uninstall takes no arguments\n\n%s", usage)
+		return ExitUsage
+	}
+
+	dir, err := schedule.UnitDir()
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	_, removed, err := schedule.WriteUnits(nil, dir, "")
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	if err := c.disableTimers(dir, removed); err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+
+	for _, timer := range removed {
+		fmt.Fprintf(c.Stdout, "Removed %s\n", timer)
+	}
+	if len(removed) == 0 {
+		fmt.Fprintln(c.Stdout, "No schedule timers installed")
+	}
+	return ExitOK
+}
+
+// disableTimers stops the given timers and deletes their unit files
+func (c *CLI) disableTimers(dir string, timers []string) error {
+	if len(timers) == 0 {
+		return nil
+	}
+	// Timers may never have been enabled, deleting their files is what matters
+	if err := systemctl(append([]string{"disable", "--now"}, timers...)...); err != nil {
+		fmt.Fprintf(c.Stderr, "Warning: %v\n", err)
+	}
+	return schedule.RemoveUnits(dir, timers)
+}
+
+// printScheduleRunner reminds that schedules need the daemon or installed timers
+func (c *CLI) printScheduleRunner() {
+	if _, err := daemon.Dial(); err == nil {
+		return
+	}
+	fmt.Fprintln(c.Stderr, "Warning: schedules run while gts daemon is running, or after: gts schedule install")
+}
+
+// systemctl runs "systemctl --user" with args
+func systemctl(args ...string) error {
+	cmd := exec.Command("systemctl", append([]string{"--user"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return fmt.Errorf("systemctl --user %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+		}
+		return fmt.Errorf("failed to run systemctl: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/daemon"
+	"github.com/kaganyuksek/gotosleep/internal/hooks"
+	"github.com/kaganyuksek/gotosleep/internal/schedule"
+	"github.com/kaganyuksek/gotosleep/internal/scheduler"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// runStart handles "gts start <duration|time> [--action A] [--dry-run] [--yes]"
+func (c *CLI) runStart(args []string) int {
+	fs := c.newFlagSet("start")
+	actionName := fs.String("action", "poweroff", "power action to perform")
+	dryRun := fs.Bool("dry-run", false, "simulate without scheduling a real shutdown")
+	yes := fs.Bool("yes", false, "skip the confirmation prompt")
+	fs.BoolVar(yes, "y", false, "skip the confirmation prompt")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) == 0 {
+		fmt.Fprintf(c.Stderr, "Error: start expects a duration or time\n\n%s", usage)
+		return ExitUsage
+	}
+
+	// Absolute times may span several arguments, e.g. "tomorrow 07:00"
+	target, err := utils.ParseTarget(strings.Join(positional, " "), time.Now())
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitInvalidDuration
+	}
+
+	action, err := shutdown.ParseAction(*actionName)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitUsage
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+
+	// Settings provide the default, an explicit flag wins
+	dry := cfg.Settings.DryRunDefault
+	if isFlagSet(fs, "dry-run") {
+		dry = *dryRun
+	}
+
+	// Refuse early, before asking, when the action lands in a forbidden window
+	if err := c.checkWindows(cfg.Settings.ForbiddenWindows, target.EndTime(time.Now())); err != nil {
+		return c.exitCode(err)
+	}
+
+	if cfg.Settings.Confirm && !*yes {
+		when := fmt.Sprintf("in %s", utils.FormatDuration(target.Minutes))
+		if target.IsAbsolute() {
+			when = fmt.Sprintf("at %s (in %s)",
+				target.At.Format("2006-01-02 15:04"),
+				utils.FormatDuration(int(time.Until(target.At).Minutes())))
+		}
+		question := fmt.Sprintf("Schedule %s %s?", action, when)
+		if dry {
+			question = fmt.Sprintf("Schedule a dry-run %s %s?", action, when)
+		}
+		if !c.confirm(question) {
+			fmt.Fprintln(c.Stderr, "Aborted")
+			return ExitError
+		}
+	}
+
+	ctl := daemon.Connect(cfg)
+	if err := ctl.StartAt(target.EndTime(time.Now()), action, dry); err != nil {
+		return c.exitCode(err)
+	}
+
+	status, err := ctl.Status()
+	if err != nil {
+		return c.exitCode(err)
+	}
+	if status.Job == nil {
+		fmt.Fprintln(c.Stderr, "Error: scheduled job not found")
+		return ExitError
+	}
+
+	job := status.Job
+	fmt.Fprintf(c.Stdout, "%s scheduled for %s (in %s)\n",
+		action,
+		job.EndTime.Format("2006-01-02 15:04:05"),
+		utils.FormatDuration(job.DurationSec/60))
+	if dry {
+		fmt.Fprintf(c.Stdout, "Dry run: %s was not executed\n", job.Command)
+	}
+	c.printHooks(cfg.Settings, job.Owner, dry)
+	return ExitOK
+}
+
+// printHooks tells which pre-action hooks will run for a newly started job
+func (c *CLI) printHooks(settings config.Settings, owner string, dryRun bool) {
+	if len(settings.Hooks) == 0 {
+		return
+	}
+	verb := "Hooks to run"
+	if dryRun {
+		verb = "Dry run: hooks that would run"
+	}
+	fmt.Fprintf(c.Stdout, "%s %s before the action:\n", verb, settings.HookLead())
+	for i, hook := range settings.Hooks {
+		abort := ""
+		if hook.AbortOnFailure {
+			abort = ", aborts on failure"
+		}
+		fmt.Fprintf(c.Stdout, "  %d. %s: %s (timeout %s%s)\n", i+1, hook.Name, hook.Command, hooks.Timeout(hook), abort)
+	}
+
+	// Only dry runs start without the daemon when hooks are configured
+	if owner != config.OwnerDaemon {
+		fmt.Fprintln(c.Stderr, "Warning: without gts daemon the real job is refused, hooks need the daemon")
+	}
+}
+
+// checkWindows refuses an action at the given time when a forbidden window
+// does, and tells when a deferring window will push it back
+func (c *CLI) checkWindows(windows []config.Window, at time.Time) error {
+	allowed, err := scheduler.CheckWindows(windows, at)
+	if err != nil {
+		return err
+	}
+	if w, _, ok := schedule.Blocking(windows, at); ok {
+		fmt.Fprintf(c.Stdout, "%s falls in the forbidden window %s, the action is pushed back to %s\n",
+			at.Format("15:04"), schedule.DescribeWindow(*w), allowed.Format("15:04"))
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/daemon"
+	"github.com/kaganyuksek/gotosleep/internal/scheduler"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+	"github.com/kaganyuksek/gotosleep/internal/trigger"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// runStatus handles "gts status [--json]"
+func (c *CLI) runStatus(args []string) int {
+	fs := c.newFlagSet("status")
+	asJSON := fs.Bool("json", false, "print the status as JSON")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 0 {
+		fmt.Fprintf(c.Stderr, "Error: status takes no arguments\n\n%s", usage)
+		return ExitUsage
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+
+	status, err := daemon.Connect(cfg).Status()
+	if err != nil {
+		return c.exitCode(err)
+	}
+
+	if *asJSON {
+		return c.printStatusJSON(status)
+	}
+
+	job := status.Job
+	if job == nil {
+		fmt.Fprintln(c.Stdout, "No scheduled shutdown")
+		return ExitNoActiveJob
+	}
+
+	dryRun := "no"
+	if job.DryRun {
+		dryRun = "yes"
+	}
+
+	if job.Waiting {
+		fmt.Fprintln(c.Stdout, "Shutdown waiting for its trigger")
+		fmt.Fprintf(c.Stdout, "  Started:   %s\n", job.StartTime.Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(c.Stdout, "  Countdown: %s once met\n", utils.FormatDuration(job.DurationSec/60))
+	} else {
+		fmt.Fprintln(c.Stdout, "Shutdown scheduled")
+		fmt.Fprintf(c.Stdout, "  Started:   %s\n", job.StartTime.Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(c.Stdout, "  Scheduled: %s\n", job.EndTime.Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(c.Stdout, "  Remaining: %s\n", utils.FormatCountdown(time.Until(job.EndTime)))
+	}
+	if job.Trigger != nil {
+		fmt.Fprintf(c.Stdout, "  Trigger:   %s\n", trigger.Describe(*job.Trigger))
+		if job.TriggerDetail != "" {
+			fmt.Fprintf(c.Stdout, "  Now:       %s\n", job.TriggerDetail)
+		}
+	}
+	fmt.Fprintf(c.Stdout, "  Action:    %s\n", job.Action)
+	fmt.Fprintf(c.Stdout, "  Command:   %s\n", job.Command)
+	fmt.Fprintf(c.Stdout, "  Timed by:  %s\n", job.Owner)
+	fmt.Fprintf(c.Stdout, "  Dry run:   %s\n", dryRun)
+	// Only shutdowns are scheduled with the OS, other actions wait in a timer
+	action := shutdown.ActionOrDefault(job.Action)
+	if !job.Waiting && !job.DryRun && (action == shutdown.ActionPowerOff || action == shutdown.ActionReboot) {
+		c.printOSState()
+	}
+	return ExitOK
+}
+
+// printOSState shows the action the OS itself has scheduled, when the
+// executor can ask, so a timer lost outside of gts does not go unnoticed
+func (c *CLI) printOSState() {
+	reader, ok := shutdown.NewExecutor().(shutdown.StateReader)
+	if !ok {
+		return
+	}
+
+	scheduled, ok, err := reader.Scheduled()
+	switch {
+	case err != nil:
+		fmt.Fprintf(c.Stdout, "  OS:        %v\n", err)
+	case !ok:
+		fmt.Fprintln(c.Stdout, "  OS:        no shutdown scheduled")
+	default:
+		fmt.Fprintf(c.Stdout, "  OS:        %s at %s\n", scheduled.Action, scheduled.At.Format("2006-01-02 15:04:05"))
+	}
+}
+
+// printStatusJSON writes the status snapshot as JSON
+func (c *CLI) printStatusJSON(status scheduler.Status) int {
+	enc := json.NewEncoder(c.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(status); err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+
+	if !status.Active {
+		return ExitNoActiveJob
+	}
+	return ExitOK
+}
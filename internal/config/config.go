@@ -1,59 +1,193 @@
 package config
 
 import (
-	"encoding/json"
-	"fmt"
-	"os"
-	"path/filepath"
+	"strconv"
 	"time"
 )
 
-// Config represents the application configuration
+// Config represents the application configuration. Settings, presets and
+// schedules are kept in config.toml, the active job in state.json and the
+// history in history.jsonl; the JSON tags describe the single state file
+// written before version 3.
 type Config struct {
-	Version      int        `json:"version"`
-	Presets      []Preset   `json:"presets"`
-	HistoryLimit int        `json:"history_limit"`
-	History      []History  `json:"history"`
-	Settings     Settings   `json:"settings"`
-	ActiveJob    *ActiveJob `json:"active_job"`
+	Version   int        `json:"version"`
+	Presets   []Preset   `json:"presets"`
+	Schedules []Schedule `json:"schedules,omitempty"`
+	Retention Retention  `json:"-"`
+	History   []History  `json:"history"`
+	Settings  Settings   `json:"settings"`
+	ActiveJob *ActiveJob `json:"active_job"`
+
+	files   files             // config and state file as last read or written
+	saved   map[string]string // history records in the history file by ID
+	deleted []string          // IDs of history entries deleted since the last save
+	lock    *fileLock         // state file lock while held
+	locks   int               // nested Lock calls holding lock
+
+	migratedFrom int    // version of the file before it was migrated, 0 when it was not
+	original     []byte // contents of the file before it was migrated
 }
 
 // Preset represents a quick duration preset
 type Preset struct {
-	Label   string `json:"label"`
-	Minutes int    `json:"minutes"`
+	Label   string `json:"label" toml:"label"`
+	Minutes int    `json:"minutes" toml:"minutes"`
+	Action  string `json:"action,omitempty" toml:"action,omitempty"`
+}
+
+// Schedule is a recurring timer, such as a bedtime on every weekday. The job
+// starts LeadMinutes before Time so it can still be cancelled for the night.
+type Schedule struct {
+	ID          string         `json:"id" toml:"id"`
+	Name        string         `json:"name,omitempty" toml:"name,omitempty"`
+	Days        []time.Weekday `json:"days,omitempty" toml:"days,omitempty"`         // 0 is Sunday
+	Time        string         `json:"time,omitempty" toml:"time,omitempty"`         // wall-clock time, "23:30"
+	Cron        string         `json:"cron,omitempty" toml:"cron,omitempty"`         // cron expression used instead of Days and Time
+	Timezone    string         `json:"timezone,omitempty" toml:"timezone,omitempty"` // IANA zone of Time or Cron, local when empty
+	Action      string         `json:"action,omitempty" toml:"action,omitempty"`
+	LeadMinutes int            `json:"lead_minutes" toml:"lead_minutes"`
+	DryRun      bool           `json:"dry_run,omitempty" toml:"dry_run,omitempty"`
+	Enabled     bool           `json:"enabled" toml:"enabled"`
+	LastArmed   time.Time      `json:"last_armed,omitempty" toml:"-"` // occurrence whose job was last started
 }
 
 // History represents a past shutdown event
 type History struct {
-	ID              string    `json:"id"`
-	CreatedAt       time.Time `json:"created_at"`
-	DurationSeconds int       `json:"duration_seconds"`
-	ScheduledFor    time.Time `json:"scheduled_for"`
-	Status          string    `json:"status"` // ok, cancelled, failed, dry-run
-	OS              string    `json:"os"`
-	Command         string    `json:"command"`
+	ID              string       `json:"id"`
+	CreatedAt       time.Time    `json:"created_at"`
+	DurationSeconds int          `json:"duration_seconds"`
+	ScheduledFor    time.Time    `json:"scheduled_for"`
+	Status          string       `json:"status"` // ok, cancelled, failed, dry-run
+	OS              string       `json:"os"`
+	Command         string       `json:"command"`
+	Action          string       `json:"action,omitempty"`
+	Adjustments     []Adjustment `json:"adjustments,omitempty"`
+	Hooks           []HookResult `json:"hooks,omitempty"`
+	Trigger         *Trigger     `json:"trigger,omitempty"`
+	Run             *RunResult   `json:"run,omitempty"`
+	Schedule        string       `json:"schedule,omitempty"` // ID of the schedule that started the job
+}
+
+// Retention limits how much history is kept, zero fields keep everything
+type Retention struct {
+	MaxEntries int `toml:"max_entries"`  // newest entries kept
+	MaxAgeDays int `toml:"max_age_days"` // days entries are kept after they were created
+}
+
+// Adjustment records a running timer being extended or shortened
+type Adjustment struct {
+	At           time.Time `json:"at"`
+	DeltaSeconds int       `json:"delta_seconds"` // negative when shortened
+	ScheduledFor time.Time `json:"scheduled_for"`
+}
+
+// Hook is a command run before the power action
+type Hook struct {
+	Name           string `json:"name" toml:"name"`
+	Command        string `json:"command" toml:"command"`                 // run through sh -c, or cmd /C on Windows
+	TimeoutSeconds int    `json:"timeout_seconds" toml:"timeout_seconds"` // 0 uses the default timeout
+	AbortOnFailure bool   `json:"abort_on_failure" toml:"abort_on_failure"`
+}
+
+// HookResult records one run of a hook
+type HookResult struct {
+	Name       string    `json:"name"`
+	Command    string    `json:"command"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	ExitCode   int       `json:"exit_code"` // -1 when the command did not exit on its own
+	Output     string    `json:"output,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	TimedOut   bool      `json:"timed_out,omitempty"`
+	DryRun     bool      `json:"dry_run,omitempty"`
+}
+
+// RunResult records the command a "gts run" job waited for
+type RunResult struct {
+	Command    string    `json:"command"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	ExitCode   int       `json:"exit_code"` // -1 when killed by a signal
+	When       string    `json:"when"`      // one of the RunWhen* constants
+}
+
+// Failed reports whether the hook did not complete successfully
+func (r HookResult) Failed() bool {
+	return !r.DryRun && (r.ExitCode != 0 || r.TimedOut || r.Error != "")
 }
 
 // Settings represents application settings
 type Settings struct {
-	Confirm       bool   `json:"confirm"`
-	DryRunDefault bool   `json:"dry_run_default"`
-	Language      string `json:"language"`
+	Confirm          bool     `json:"confirm" toml:"confirm"`
+	DryRunDefault    bool     `json:"dry_run_default" toml:"dry_run_default"`
+	Language         string   `json:"language" toml:"language"`
+	WarningMinutes   []int    `json:"warning_minutes" toml:"warning_minutes"` // empty disables warnings
+	SnoozeMinutes    int      `json:"snooze_minutes" toml:"snooze_minutes"`
+	Hooks            []Hook   `json:"hooks,omitempty" toml:"hooks,omitempty"`
+	HookLeadSeconds  int      `json:"hook_lead_seconds,omitempty" toml:"hook_lead_seconds,omitzero"`  // 0 uses DefaultHookLead
+	GraceMinutes     int      `json:"grace_minutes" toml:"grace_minutes"`                             // countdown once a trigger is met
+	ForbiddenWindows []Window `json:"forbidden_windows,omitempty" toml:"forbidden_windows,omitempty"` // power actions never run inside these
+}
+
+// Window is a recurring period of the day, such as a nightly backup from
+// 02:00 to 03:00, in which power actions are not allowed
+type Window struct {
+	Name  string         `json:"name,omitempty" toml:"name,omitempty"`
+	Days  []time.Weekday `json:"days,omitempty" toml:"days,omitempty"`   // days the window starts on, every day when empty
+	From  string         `json:"from" toml:"from"`                       // local wall-clock time, "02:00"
+	To    string         `json:"to" toml:"to"`                           // ends the next day when not after From
+	Defer bool           `json:"defer,omitempty" toml:"defer,omitempty"` // push actions back to the end instead of refusing them
+}
+
+// HookLead returns how long before the action the hooks are started
+func (s Settings) HookLead() time.Duration {
+	if s.HookLeadSeconds <= 0 {
+		return DefaultHookLead
+	}
+	return time.Duration(s.HookLeadSeconds) * time.Second
+}
+
+// Trigger describes the condition a job waits for before its countdown runs
+type Trigger struct {
+	Kind         string  `json:"kind"`                    // one of the Trigger* constants
+	IdleSeconds  int     `json:"idle_seconds,omitempty"`  // idle: user inactivity needed
+	PID          int     `json:"pid,omitempty"`           // process: PID to wait for, 0 to match by name
+	Process      string  `json:"process,omitempty"`       // process: command name to wait for, or the name of PID
+	ProcessStart uint64  `json:"process_start,omitempty"` // process: start time of PID, guards against PID reuse
+	Interface    string  `json:"interface,omitempty"`     // network: interface to watch, empty for all but loopback
+	RateBytes    int64   `json:"rate_bytes,omitempty"`    // network: bytes per second that still count as quiet
+	QuietSeconds int     `json:"quiet_seconds,omitempty"` // network, load, cpu: how long the level must stay low
+	LoadBelow    float64 `json:"load_below,omitempty"`    // load: one-minute load average that counts as quiet
+	CPUBelow     float64 `json:"cpu_below,omitempty"`     // cpu: usage of all CPUs in percent that counts as quiet
+	Media        string  `json:"media,omitempty"`         // media: one of the Media* constants
+	Player       string  `json:"player,omitempty"`        // media: MPRIS player to watch, empty for any
+	Track        string  `json:"track,omitempty"`         // media: track playing when the job was armed
+	BatteryBelow int     `json:"battery_below,omitempty"` // battery: charge in percent the battery must drop below
+	KeepOnAC     bool    `json:"keep_on_ac,omitempty"`    // battery: wait again instead of cancelling when AC returns
 }
 
 // ActiveJob represents currently running shutdown job
 type ActiveJob struct {
 	StartTime   time.Time `json:"start_time"`
-	EndTime     time.Time `json:"end_time"`
+	EndTime     time.Time `json:"end_time"` // zero while waiting
 	DurationSec int       `json:"duration_sec"`
 	Command     string    `json:"command"`
+	DryRun      bool      `json:"dry_run"`
+	Action      string    `json:"action,omitempty"`
+	Owner       string    `json:"owner,omitempty"` // empty when timed by the OS
+	Trigger     *Trigger  `json:"trigger,omitempty"`
+	Waiting     bool      `json:"waiting,omitempty"` // trigger not met, countdown not running
+}
+
+// Expired reports whether the countdown of the job has run out
+func (j *ActiveJob) Expired(now time.Time) bool {
+	return !j.Waiting && now.After(j.EndTime)
 }
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
-		Version: 1,
+		Version: CurrentVersion,
 		Presets: []Preset{
 			{Label: "15m", Minutes: 15},
 			{Label: "30m", Minutes: 30},
@@ -62,99 +196,102 @@ func DefaultConfig() *Config {
 			{Label: "90m", Minutes: 90},
 			{Label: "120m", Minutes: 120},
 		},
-		HistoryLimit: 20,
-		History:      []History{},
+		History: []History{},
 		Settings: Settings{
-			Confirm:       true,
-			DryRunDefault: false,
-			Language:      "en",
+			Confirm:        true,
+			DryRunDefault:  false,
+			Language:       "en",
+			WarningMinutes: DefaultWarningMinutes(),
+			SnoozeMinutes:  DefaultSnoozeMinutes,
+			GraceMinutes:   DefaultGraceMinutes,
 		},
 		ActiveJob: nil,
 	}
 }
 
-// ConfigPath returns the path to the config file
-func ConfigPath() (string, error) {
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get config dir: %w", err)
-	}
-
-	gtsDir := filepath.Join(configDir, "gts")
-	if err := os.MkdirAll(gtsDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create config dir: %w", err)
-	}
-
-	return filepath.Join(gtsDir, "state.json"), nil
+// AddHistory adds a new history entry, the retention applies when saving
+func (c *Config) AddHistory(h History) {
+	c.History = append([]History{h}, c.History...)
 }
 
-// Load loads the configuration from disk
-func Load() (*Config, error) {
-	path, err := ConfigPath()
-	if err != nil {
-		return nil, err
-	}
-
-	// If file doesn't exist, return default config
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return DefaultConfig(), nil
+// UpdateHistoryStatus updates the status of the most recent history entry
+func (c *Config) UpdateHistoryStatus(status string) {
+	if len(c.History) > 0 {
+		c.History[0].Status = status
 	}
+}
 
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config: %w", err)
+// RescheduleHistory sets when the most recent history entry is scheduled for
+func (c *Config) RescheduleHistory(scheduledFor time.Time) {
+	if len(c.History) > 0 {
+		c.History[0].ScheduledFor = scheduledFor
 	}
+}
 
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config: %w", err)
+// AdjustHistory records an adjustment on the most recent history entry
+func (c *Config) AdjustHistory(adj Adjustment, command string) {
+	if len(c.History) > 0 {
+		h := &c.History[0]
+		h.Adjustments = append(h.Adjustments, adj)
+		h.ScheduledFor = adj.ScheduledFor
+		h.DurationSeconds += adj.DeltaSeconds
+		h.Command = command
 	}
+}
 
-	// Check if active job has expired and clean it up
-	if cfg.ActiveJob != nil {
-		now := time.Now()
-		if now.After(cfg.ActiveJob.EndTime) {
-			// Job has expired, clear it
-			cfg.ActiveJob = nil
-			// Save the cleaned config
-			_ = cfg.Save()
+// RecordHooks appends hook results to the history entry created at createdAt
+func (c *Config) RecordHooks(createdAt time.Time, results []HookResult) bool {
+	for i := range c.History {
+		if c.History[i].CreatedAt.Equal(createdAt) {
+			c.History[i].Hooks = append(c.History[i].Hooks, results...)
+			return true
 		}
 	}
-
-	return &cfg, nil
+	return false
 }
 
-// Save saves the configuration to disk
-func (c *Config) Save() error {
-	path, err := ConfigPath()
-	if err != nil {
-		return err
-	}
-
-	data, err := json.MarshalIndent(c, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+// RecordRun attaches the result of a "gts run" command to the history entry
+// created at createdAt and reports whether the entry was found
+func (c *Config) RecordRun(createdAt time.Time, run RunResult) bool {
+	for i := range c.History {
+		if c.History[i].CreatedAt.Equal(createdAt) {
+			c.History[i].Run = &run
+			return true
+		}
 	}
+	return false
+}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+// FindSchedule returns the schedule with the given ID, or nil
+func (c *Config) FindSchedule(id string) *Schedule {
+	for i := range c.Schedules {
+		if c.Schedules[i].ID == id {
+			return &c.Schedules[i]
+		}
 	}
-
 	return nil
 }
 
-// AddHistory adds a new history entry and maintains the limit
-func (c *Config) AddHistory(h History) {
-	c.History = append([]History{h}, c.History...)
-	if len(c.History) > c.HistoryLimit {
-		c.History = c.History[:c.HistoryLimit]
+// AddSchedule appends s under the next free numeric ID and returns the ID
+func (c *Config) AddSchedule(s Schedule) string {
+	next := 1
+	for _, existing := range c.Schedules {
+		if id, err := strconv.Atoi(existing.ID); err == nil && id >= next {
+			next = id + 1
+		}
 	}
+	s.ID = strconv.Itoa(next)
+	c.Schedules = append(c.Schedules, s)
+	return s.ID
 }
 
-// UpdateHistoryStatus updates the status of the most recent history entry
-func (c *Config) UpdateHistoryStatus(status string) {
-	if len(c.History) > 0 {
-		c.History[0].Status = status
+// DeleteSchedule removes a schedule by ID
+func (c *Config) DeleteSchedule(id string) {
+	for i, s := range c.Schedules {
+		if s.ID == id {
+			c.Schedules = append(c.Schedules[:i], c.Schedules[i+1:]...)
+			return
+		}
 	}
 }
 
@@ -163,6 +300,7 @@ func (c *Config) DeleteHistory(id string) {
 	for i, h := range c.History {
 		if h.ID == id {
 			c.History = append(c.History[:i], c.History[i+1:]...)
+			c.deleted = append(c.deleted, id)
 			return
 		}
 	}
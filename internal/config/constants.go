@@ -1,9 +1,65 @@
 package config
 
+import "time"
+
 // Status constants for history entries
 const (
 	StatusOK        = "ok"
 	StatusCancelled = "cancelled"
 	StatusFailed    = "failed"
 	StatusDryRun    = "dry-run"
+	StatusMissed    = "missed"
+	StatusAborted   = "aborted" // a pre-action hook failed
+	StatusSkipped   = "skipped" // a "gts run" command did not exit the way the job waited for
+)
+
+// OwnerDaemon marks active jobs timed by the gts daemon instead of the OS
+const OwnerDaemon = "daemon"
+
+// Trigger kinds
+const (
+	TriggerIdle    = "idle"    // the user session has been idle long enough
+	TriggerProcess = "process" // a watched process has exited
+	TriggerNetwork = "network" // network traffic has been quiet long enough
+	TriggerLoad    = "load"    // the load average has been low long enough
+	TriggerCPU     = "cpu"     // CPU usage has been low long enough
+	TriggerMedia   = "media"   // media playback has stopped
+	TriggerBattery = "battery" // the battery is low while discharging
+)
+
+// When a "gts run" job performs its action
+const (
+	RunWhenAlways  = "always"  // whatever the exit status
+	RunWhenSuccess = "success" // only when the command exits with 0
+	RunWhenFailure = "failure" // only when the command fails
+)
+
+// What a media trigger waits for
+const (
+	MediaStop  = "stop"  // no player is playing anymore
+	MediaTrack = "track" // the track playing when the job was armed has ended
 )
+
+// DefaultQuietMinutes is how long a quiet trigger waits when no window is given
+const DefaultQuietMinutes = 5
+
+// DefaultNetworkQuietRate is the traffic in bytes per second a network
+// trigger treats as quiet when no rate is given
+const DefaultNetworkQuietRate = 10 << 10
+
+// DefaultScheduleLeadMinutes is how long before its time a schedule starts its job
+const DefaultScheduleLeadMinutes = 15
+
+// DefaultSnoozeMinutes is how far the snooze button of a warning pushes the job back
+const DefaultSnoozeMinutes = 10
+
+// DefaultWarningMinutes returns how many minutes before the action warnings are sent
+func DefaultWarningMinutes() []int {
+	return []int{10, 5, 1}
+}
+
+// DefaultHookLead is how long before the action hooks run unless configured
+const DefaultHookLead = time.Minute
+
+// DefaultGraceMinutes is the countdown started once the trigger of a job is met
+const DefaultGraceMinutes = 5
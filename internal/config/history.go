@@ -0,0 +1,198 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// historyRecord is a line of the history file. Entries are appended again
+// whenever they change and the last record of an ID wins, a deleted record
+// removes the entry.
+type historyRecord struct {
+	History
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// tombstone is the record appended for a deleted entry
+type tombstone struct {
+	ID      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+// readHistory reads the history file at path, or at old while it was not
+// moved yet, newest entry first, and the latest record of each entry by ID
+func readHistory(path, old string) ([]History, map[string]string, error) {
+	data, err := readFile(path)
+	if data == nil && err == nil && old != "" {
+		data, err = readFile(old)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var order []string
+	entries := map[string]History{}
+	saved := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var record historyRecord
+		// A crash while appending leaves a partial last line, which is skipped
+		if err := json.Unmarshal(line, &record); err != nil || record.ID == "" {
+			continue
+		}
+
+		if record.Deleted {
+			delete(entries, record.ID)
+			delete(saved, record.ID)
+			continue
+		}
+		if _, ok := entries[record.ID]; !ok {
+			order = append(order, record.ID)
+		}
+		entries[record.ID] = record.History
+		saved[record.ID] = string(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	// Entries are listed in the order they were first added
+	history := []History{}
+	for i := len(order) - 1; i >= 0; i-- {
+		if h, ok := entries[order[i]]; ok {
+			history = append(history, h)
+			delete(entries, order[i])
+		}
+	}
+	return history, saved, nil
+}
+
+// Apply returns the entries of history, newest first, that r keeps at now
+func (r Retention) Apply(history []History, now time.Time) []History {
+	if r.MaxAgeDays > 0 {
+		cutoff := now.AddDate(0, 0, -r.MaxAgeDays)
+		kept := make([]History, 0, len(history))
+		for _, h := range history {
+			if !h.CreatedAt.Before(cutoff) {
+				kept = append(kept, h)
+			}
+		}
+		history = kept
+	}
+	if r.MaxEntries > 0 && len(history) > r.MaxEntries {
+		history = history[:r.MaxEntries]
+	}
+	return history
+}
+
+// appendHistory appends records for the entries added, changed or deleted
+// since the history file was read
+func (c *Config) appendHistory(path string) error {
+	if c.saved == nil {
+		c.saved = map[string]string{}
+	}
+
+	var buf bytes.Buffer
+	written := map[string]string{}
+	for i := len(c.History) - 1; i >= 0; i-- {
+		h := c.History[i]
+		line, err := json.Marshal(h)
+		if err != nil {
+			return fmt.Errorf("failed to marshal history: %w", err)
+		}
+		if c.saved[h.ID] == string(line) {
+			continue
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		written[h.ID] = string(line)
+	}
+	for _, id := range c.deleted {
+		if _, ok := c.saved[id]; !ok {
+			continue
+		}
+		line, err := json.Marshal(tombstone{ID: id, Deleted: true})
+		if err != nil {
+			return fmt.Errorf("failed to marshal history: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		written[id] = ""
+	}
+	if buf.Len() == 0 {
+		c.deleted = nil
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+
+	// Finish a partial line left by a crash, or the first record would join it
+	data := buf.Bytes()
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			data = append([]byte{'\n'}, data...)
+		}
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+
+	for id, line := range written {
+		if line == "" {
+			delete(c.saved, id)
+		} else {
+			c.saved[id] = line
+		}
+	}
+	c.deleted = nil
+	return nil
+}
+
+// compactHistory rewrites the history file with the latest record of each
+// entry the retention keeps. The file is read again while the lock is held,
+// so entries other instances appended since c was loaded are kept as well,
+// and c is left holding what the file does.
+func (c *Config) compactHistory(path string) error {
+	history, saved, err := readHistory(path, "")
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	kept := map[string]string{}
+	retained := c.Retention.Apply(history, time.Now())
+	for i := len(retained) - 1; i >= 0; i-- {
+		line := saved[retained[i].ID]
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+		kept[retained[i].ID] = line
+	}
+	if err := writeAtomic(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to compact history: %w", err)
+	}
+
+	c.History, c.saved = retained, kept
+	return nil
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrModified is returned when another gts instance changed the config or
+// state file after the config was loaded, saving would overwrite its changes
+var ErrModified = errors.New("the config was changed by another gts instance, reload and try again")
+
+// lockTimeout bounds the wait for another instance to release the lock
+const lockTimeout = 5 * time.Second
+
+// fileLock is the advisory lock guarding the config, state and history
+// files. It is taken on a separate file, saves replace the others.
+type fileLock struct {
+	paths Paths
+	file  *os.File
+}
+
+// Update loads the config and runs fn on it while holding the state file
+// lock, then saves it unless fn failed, so no other instance can write in
+// between
+func Update(fn func(cfg *Config) error) error {
+	paths, err := ResolvePaths()
+	if err != nil {
+		return err
+	}
+
+	l, err := acquire(paths)
+	if err != nil {
+		return err
+	}
+	defer l.release()
+
+	cfg, err := read(paths)
+	if err != nil {
+		return err
+	}
+	if cfg.Version > CurrentVersion {
+		return &VersionError{Version: cfg.Version}
+	}
+	cfg.lock, cfg.locks = l, 1
+	defer func() { cfg.lock, cfg.locks = nil, 0 }()
+
+	if err := fn(cfg); err != nil {
+		return err
+	}
+	return cfg.Save()
+}
+
+// Lock takes the state file lock for c until the returned function is
+// called, so saves in between cannot interleave with other instances. It
+// fails with ErrModified when the file changed since c was loaded, and with
+// a VersionError when a newer gts wrote it. Calls nest, only the outermost
+// one takes the lock.
+func (c *Config) Lock() (func(), error) {
+	if c.lock != nil {
+		c.locks++
+		return c.unlock, nil
+	}
+
+	// Fields of a newer schema would be lost on save
+	if c.Version > CurrentVersion {
+		return nil, &VersionError{Version: c.Version}
+	}
+
+	paths, err := ResolvePaths()
+	if err != nil {
+		return nil, err
+	}
+	l, err := acquire(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := readFiles(paths)
+	if err != nil {
+		l.release()
+		return nil, err
+	}
+	if current.checksum() != c.files.checksum() {
+		l.release()
+		return nil, ErrModified
+	}
+
+	c.lock, c.locks = l, 1
+	return c.unlock, nil
+}
+
+// Modified reports whether another instance wrote the files since c was
+// loaded or saved, which Lock would fail on with ErrModified. It reads the
+// config and state file only, the history is left alone.
+func (c *Config) Modified() (bool, error) {
+	paths, err := ResolvePaths()
+	if err != nil {
+		return false, err
+	}
+	current, err := readFiles(paths)
+	if err != nil {
+		return false, err
+	}
+	return current.checksum() != c.files.checksum(), nil
+}
+
+// unlock releases one Lock call, the lock itself with the outermost one
+func (c *Config) unlock() {
+	c.locks--
+	if c.locks == 0 {
+		c.lock.release()
+		c.lock = nil
+	}
+}
+
+// acquire takes the lock on the files at paths, waiting up to lockTimeout
+// for another instance to release it
+func acquire(paths Paths) (*fileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(paths.State), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create state dir: %w", err)
+	}
+	f, err := os.OpenFile(paths.State+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock state file: %w", err)
+		}
+		if ok {
+			return &fileLock{paths: paths, file: f}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock state file: still locked by another gts instance after %s", lockTimeout)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// release drops the lock, closing the file would release it as well
+func (l *fileLock) release() {
+	_ = unlockFile(l.file)
+	l.file.Close()
+}
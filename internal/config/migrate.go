@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// CurrentVersion is the version of the config schema written by this build
+const CurrentVersion = 4
+
+// splitVersion moved settings, presets and schedules from state.json to
+// config.toml and history to history.jsonl
+const splitVersion = 3
+
+// DefaultHistoryLimit is how many history entries were kept before
+// version 4 kept all of them
+const DefaultHistoryLimit = 20
+
+// migration upgrades a decoded state file by one version in place
+type migration func(doc map[string]interface{}) error
+
+// migrations[i] upgrades a file of version i+1 to version i+2
+var migrations = []migration{
+	migrateV1,
+	migrateV2,
+	migrateV3,
+}
+
+// VersionError is returned when saving a config written by a newer gts,
+// whose fields this build does not know and would drop
+type VersionError struct {
+	Version int
+}
+
+// Error explains that the file is left alone
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("the config has version %d but this gts only knows up to version %d, refusing to overwrite it", e.Version, CurrentVersion)
+}
+
+// migrate upgrades the contents of a single state file in data to
+// CurrentVersion and returns the version they were written with. Files of
+// the current or a newer version are returned as they are.
+func migrate(data []byte) ([]byte, int, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+
+	// The version was always 1 before migrations existed
+	version := 1
+	if v, ok := doc["version"].(float64); ok && v > 1 {
+		version = int(v)
+	}
+	if version >= CurrentVersion {
+		return data, version, nil
+	}
+	if err := upgrade(doc, version); err != nil {
+		return nil, version, err
+	}
+
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, version, err
+	}
+	return migrated, version, nil
+}
+
+// migrateSplit upgrades config.toml in data to CurrentVersion the same way
+// and returns the version it was written with. A file without a version is
+// taken to be of the first version that had config.toml.
+func migrateSplit(data []byte) ([]byte, int, error) {
+	var doc map[string]interface{}
+	if _, err := toml.Decode(string(data), &doc); err != nil {
+		return nil, 0, err
+	}
+
+	version := splitVersion
+	if v, ok := doc["version"].(int64); ok && v > splitVersion {
+		version = int(v)
+	}
+	if version >= CurrentVersion {
+		return data, version, nil
+	}
+	if err := upgrade(doc, version); err != nil {
+		return nil, version, err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+		return nil, version, err
+	}
+	return buf.Bytes(), version, nil
+}
+
+// upgrade runs the migrations from version to CurrentVersion on doc
+func upgrade(doc map[string]interface{}, version int) error {
+	for v := version; v < CurrentVersion; v++ {
+		if err := migrations[v-1](doc); err != nil {
+			return fmt.Errorf("failed to migrate from version %d: %w", v, err)
+		}
+		doc["version"] = v + 1
+	}
+	return nil
+}
+
+// migrateV1 makes the defaults of version 1 files explicit. Entries written
+// before actions existed powered off, and files without a history limit
+// would otherwise drop all history on the next entry.
+func migrateV1(doc map[string]interface{}) error {
+	if limit, _ := doc["history_limit"].(float64); limit <= 0 {
+		doc["history_limit"] = DefaultHistoryLimit
+	}
+
+	if history, ok := doc["history"].([]interface{}); ok {
+		for _, entry := range history {
+			if h, ok := entry.(map[string]interface{}); ok {
+				setDefault(h, "action", "poweroff")
+			}
+		}
+	}
+	if job, ok := doc["active_job"].(map[string]interface{}); ok {
+		setDefault(job, "action", "poweroff")
+	}
+	return nil
+}
+
+// migrateV2 leaves the document alone, version 3 only changed where its
+// parts are kept. They move to their own files when the config is saved.
+func migrateV2(doc map[string]interface{}) error {
+	return nil
+}
+
+// migrateV3 drops the history limit. It only hid older entries, which stay
+// in the history file, so nothing is lost by showing them again. Retention
+// is configured in config.toml instead.
+func migrateV3(doc map[string]interface{}) error {
+	delete(doc, "history_limit")
+	return nil
+}
+
+// setDefault sets key in m unless it holds a non-empty value
+func setDefault(m map[string]interface{}, key string, value interface{}) {
+	if v, ok := m[key]; !ok || v == nil || v == "" {
+		m[key] = value
+	}
+}
+
+// backup keeps the file at path a config was migrated from next to it, e.g.
+// state.json.v1.bak or config.toml.v3.bak, unless an earlier migration
+// already did
+func (c *Config) backup(path string) error {
+	name := fmt.Sprintf("%s.v%d.bak", path, c.migratedFrom)
+	if _, err := os.Stat(name); err == nil {
+		return nil
+	}
+	if err := writeAtomic(name, c.original); err != nil {
+		return fmt.Errorf("failed to back up %s before migration: %w", filepath.Base(path), err)
+	}
+	return nil
+}
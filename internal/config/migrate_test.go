@@ -0,0 +1,344 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// useFixture copies the files of testdata/<name> to a temporary directory
+// and points the config at them, config.toml and the state side by side
+func useFixture(t *testing.T, name string) string {
+	t.Helper()
+	dir := t.TempDir()
+	entries, err := os.ReadDir(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join("testdata", name, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, e.Name()), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Override(filepath.Join(dir, configFileName), dir)
+	t.Cleanup(func() { Override("", "") })
+	return dir
+}
+
+// readDoc decodes a legacy state file fixture
+func readDoc(t *testing.T, path string) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestMigrationSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		from    int
+		check   func(t *testing.T, before, after map[string]interface{})
+	}{
+		{
+			name:    "v1 to v2 adds the history limit and the poweroff action",
+			fixture: "testdata/v1/state.json",
+			from:    1,
+			check: func(t *testing.T, before, after map[string]interface{}) {
+				if limit := after["history_limit"]; limit != DefaultHistoryLimit {
+					t.Errorf("history_limit = %v, want %d", limit, DefaultHistoryLimit)
+				}
+				entry := after["history"].([]interface{})[0].(map[string]interface{})
+				if entry["action"] != "poweroff" {
+					t.Errorf("history action = %v, want poweroff", entry["action"])
+				}
+				job := after["active_job"].(map[string]interface{})
+				if job["action"] != "poweroff" {
+					t.Errorf("active job action = %v, want poweroff", job["action"])
+				}
+			},
+		},
+		{
+			name:    "v2 to v3 leaves the document alone",
+			fixture: "testdata/v2/state.json",
+			from:    2,
+			check: func(t *testing.T, before, after map[string]interface{}) {
+				if !reflect.DeepEqual(before, after) {
+					t.Errorf("document changed:\n%v\n%v", before, after)
+				}
+			},
+		},
+		{
+			name:    "v3 to v4 removes the history limit",
+			fixture: "testdata/v2/state.json",
+			from:    3,
+			check: func(t *testing.T, before, after map[string]interface{}) {
+				if _, ok := after["history_limit"]; ok {
+					t.Errorf("history_limit kept: %v", after["history_limit"])
+				}
+				delete(before, "history_limit")
+				if !reflect.DeepEqual(before, after) {
+					t.Errorf("other fields changed:\n%v\n%v", before, after)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := readDoc(t, tt.fixture)
+			after := readDoc(t, tt.fixture)
+			if err := migrations[tt.from-1](after); err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, before, after)
+		})
+	}
+}
+
+func TestMigrateLegacyFile(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		version   int
+		history   []string // IDs, newest first
+		actions   []string
+		lastArmed time.Time
+	}{
+		{fixture: "v1", version: 1, history: []string{"h1"}, actions: []string{"poweroff"}},
+		{
+			fixture:   "v2",
+			version:   2,
+			history:   []string{"h2", "h1"},
+			actions:   []string{"reboot", "poweroff"},
+			lastArmed: time.Date(2025, 1, 6, 23, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			dir := useFixture(t, tt.fixture)
+			original, _ := os.ReadFile(filepath.Join(dir, stateFileName))
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.migratedFrom != tt.version {
+				t.Errorf("migratedFrom = %d, want %d", cfg.migratedFrom, tt.version)
+			}
+			if err := cfg.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			// The original is kept as it was
+			backup, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("%s.v%d.bak", stateFileName, tt.version)))
+			if err != nil {
+				t.Fatalf("backup missing: %v", err)
+			}
+			if !bytes.Equal(backup, original) {
+				t.Error("backup differs from the original file")
+			}
+
+			// Settings, presets and schedules move to config.toml
+			var file configFile
+			meta, err := toml.DecodeFile(filepath.Join(dir, configFileName), &file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file.Version != CurrentVersion {
+				t.Errorf("config version = %d, want %d", file.Version, CurrentVersion)
+			}
+			if meta.IsDefined("history_limit") {
+				t.Error("config.toml still has history_limit")
+			}
+			if len(file.Presets) == 0 || file.Presets[0].Label != "20m" {
+				t.Errorf("presets = %v", file.Presets)
+			}
+
+			// The active job and schedule progress stay in state.json
+			var state stateFile
+			if err := json.Unmarshal(mustRead(t, filepath.Join(dir, stateFileName)), &state); err != nil {
+				t.Fatal(err)
+			}
+			if state.Version != CurrentVersion || state.ActiveJob == nil || state.ActiveJob.Action != "poweroff" {
+				t.Errorf("state = %+v", state)
+			}
+			if !state.LastArmed["1"].Equal(tt.lastArmed) {
+				t.Errorf("last_armed = %v, want %v", state.LastArmed["1"], tt.lastArmed)
+			}
+
+			// History is written oldest first, one record per line
+			var ids, actions []string
+			lines := readLines(t, filepath.Join(dir, historyFileName))
+			for i := len(lines) - 1; i >= 0; i-- {
+				var h History
+				if err := json.Unmarshal([]byte(lines[i]), &h); err != nil {
+					t.Fatal(err)
+				}
+				ids, actions = append(ids, h.ID), append(actions, h.Action)
+			}
+			if !reflect.DeepEqual(ids, tt.history) || !reflect.DeepEqual(actions, tt.actions) {
+				t.Errorf("history = %v %v, want %v %v", ids, actions, tt.history, tt.actions)
+			}
+
+			// Loading again reads the split files
+			again, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.migratedFrom != 0 || len(again.History) != len(tt.history) {
+				t.Errorf("reloaded migratedFrom = %d, history = %d", again.migratedFrom, len(again.History))
+			}
+		})
+	}
+}
+
+func TestMigrateSplitV3(t *testing.T) {
+	// A config.toml without a version is from the first split version
+	for _, fixture := range []string{"v3", "v3noversion"} {
+		t.Run(fixture, func(t *testing.T) {
+			dir := useFixture(t, fixture)
+			original := mustRead(t, filepath.Join(dir, configFileName))
+			history := mustRead(t, filepath.Join(dir, historyFileName))
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.migratedFrom != splitVersion || len(cfg.History) != 2 || cfg.History[0].ID != "h2" {
+				t.Fatalf("loaded migratedFrom %d with %d entries", cfg.migratedFrom, len(cfg.History))
+			}
+			if cfg.Retention != (Retention{}) {
+				t.Errorf("retention = %+v, want everything kept", cfg.Retention)
+			}
+			if want := time.Date(2025, 1, 6, 23, 30, 0, 0, time.UTC); !cfg.Schedules[0].LastArmed.Equal(want) {
+				t.Errorf("last armed = %v, want %v", cfg.Schedules[0].LastArmed, want)
+			}
+
+			// Loading alone writes nothing
+			if _, err := os.Stat(filepath.Join(dir, configFileName+".v3.bak")); !os.IsNotExist(err) {
+				t.Errorf("backup written on load: %v", err)
+			}
+			if err := cfg.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			backup, err := os.ReadFile(filepath.Join(dir, configFileName+".v3.bak"))
+			if err != nil {
+				t.Fatalf("backup missing: %v", err)
+			}
+			if !bytes.Equal(backup, original) {
+				t.Error("backup differs from the original file")
+			}
+
+			var file configFile
+			meta, err := toml.DecodeFile(filepath.Join(dir, configFileName), &file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file.Version != CurrentVersion || meta.IsDefined("history_limit") {
+				t.Errorf("config version %d, history_limit defined %v", file.Version, meta.IsDefined("history_limit"))
+			}
+			if len(file.Presets) != 1 || len(file.Schedules) != 1 || file.Settings.SnoozeMinutes != 10 {
+				t.Errorf("config lost its contents: %+v", file)
+			}
+			if !bytes.Equal(mustRead(t, filepath.Join(dir, historyFileName)), history) {
+				t.Error("history file rewritten")
+			}
+
+			// Loading again reads the upgraded file
+			again, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.migratedFrom != 0 || again.Version != CurrentVersion || len(again.History) != 2 {
+				t.Errorf("reloaded migratedFrom = %d, version = %d, history = %d", again.migratedFrom, again.Version, len(again.History))
+			}
+		})
+	}
+}
+
+func TestNewerVersionRefused(t *testing.T) {
+	dir := useFixture(t, "v5")
+	before := map[string][]byte{}
+	for _, name := range []string{configFileName, stateFileName} {
+		before[name] = mustRead(t, filepath.Join(dir, name))
+	}
+
+	// Reading works, e.g. for gts status
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != 5 {
+		t.Fatalf("version = %d, want 5", cfg.Version)
+	}
+
+	var versionErr *VersionError
+	if err := cfg.Save(); !errors.As(err, &versionErr) || versionErr.Version != 5 {
+		t.Errorf("Save() = %v, want a VersionError for version 5", err)
+	}
+	err = Update(func(cfg *Config) error {
+		t.Error("Update ran fn on a newer config")
+		return nil
+	})
+	if !errors.As(err, &versionErr) {
+		t.Errorf("Update() = %v, want a VersionError", err)
+	}
+
+	for name, data := range before {
+		if !bytes.Equal(mustRead(t, filepath.Join(dir, name)), data) {
+			t.Errorf("%s was rewritten", name)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if name := e.Name(); name == historyFileName || strings.HasSuffix(name, ".bak") || strings.HasPrefix(name, ".") {
+			t.Errorf("unexpected file %s", name)
+		}
+	}
+}
+
+// mustRead returns the contents of path
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// readLines returns the non-empty lines of path
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(mustRead(t, path)))
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
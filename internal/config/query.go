@@ -0,0 +1,63 @@
+package config
+
+import "time"
+
+// HistoryQuery selects history entries, its zero value selects all of them
+type HistoryQuery struct {
+	Statuses []string  // any of these statuses
+	Actions  []string  // any of these actions, entries without one powered off
+	OS       string    // the OS the entry was recorded on
+	Since    time.Time // created at or after
+	Until    time.Time // created before
+	Limit    int       // at most this many of the newest entries, 0 for all
+}
+
+// Matches reports whether h is selected by q, ignoring the limit
+func (q HistoryQuery) Matches(h History) bool {
+	if len(q.Statuses) > 0 && !contains(q.Statuses, h.Status) {
+		return false
+	}
+
+	action := h.Action
+	if action == "" {
+		action = "poweroff"
+	}
+	if len(q.Actions) > 0 && !contains(q.Actions, action) {
+		return false
+	}
+
+	if q.OS != "" && h.OS != q.OS {
+		return false
+	}
+	if !q.Since.IsZero() && h.CreatedAt.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !h.CreatedAt.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// QueryHistory returns the history entries selected by q, newest first
+func (c *Config) QueryHistory(q HistoryQuery) []History {
+	entries := []History{}
+	for _, h := range c.History {
+		if q.Limit > 0 && len(entries) == q.Limit {
+			break
+		}
+		if q.Matches(h) {
+			entries = append(entries, h)
+		}
+	}
+	return entries
+}
+
+// contains reports whether values holds value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
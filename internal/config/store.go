@@ -0,0 +1,529 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Names of the files gts keeps
+const (
+	configFileName  = "config.toml"
+	stateFileName   = "state.json"
+	historyFileName = "history.jsonl"
+)
+
+// configHeader starts config.toml, which users may keep in their dotfiles
+const configHeader = `# gts settings, presets and schedules. The running job is kept in state.json
+# and the history in history.jsonl, so this file only changes when you do.
+# Days are numbers, 0 is Sunday. A history limit of 0 keeps everything.
+
+`
+
+// Environment variables that override where gts keeps its files
+const (
+	EnvConfig   = "GTS_CONFIG"    // path of config.toml
+	EnvStateDir = "GTS_STATE_DIR" // directory of state.json and history.jsonl
+)
+
+// overrides holds the --config and --state-dir flags, which win over the
+// environment
+var overrides struct {
+	config, stateDir string
+}
+
+// Paths locates the files gts keeps its configuration in
+type Paths struct {
+	Config  string // config.toml: settings, presets and schedules
+	State   string // state.json: the active job, or everything before version 3
+	History string // history.jsonl: append-only history records
+
+	// State and history as kept next to config.toml before they moved to
+	// the state directory, empty when the directories are the same
+	OldState, OldHistory string
+}
+
+// files holds the contents of the config and state file
+type files struct {
+	config, state []byte
+}
+
+// configFile is the layout of config.toml
+type configFile struct {
+	Version   int        `toml:"version"`
+	History   Retention  `toml:"history"`
+	Settings  Settings   `toml:"settings"`
+	Presets   []Preset   `toml:"presets"`
+	Schedules []Schedule `toml:"schedules,omitempty"`
+}
+
+// stateFile is the layout of state.json
+type stateFile struct {
+	Version   int                  `json:"version"`
+	ActiveJob *ActiveJob           `json:"active_job"`
+	LastArmed map[string]time.Time `json:"last_armed,omitempty"` // by schedule ID
+}
+
+// Override makes gts use the config file and state directory given on the
+// command line, empty values keep the environment or the default
+func Override(configFile, stateDir string) {
+	overrides.config, overrides.stateDir = configFile, stateDir
+}
+
+// OverrideArgs returns the flags that make another gts process use the same
+// files as this one, e.g. for the systemd units that run schedules
+func OverrideArgs() []string {
+	var args []string
+	if configFile := firstSet(overrides.config, os.Getenv(EnvConfig)); configFile != "" {
+		args = append(args, "--config", absolute(configFile))
+	}
+	if stateDir := firstSet(overrides.stateDir, os.Getenv(EnvStateDir)); stateDir != "" {
+		args = append(args, "--state-dir", absolute(stateDir))
+	}
+	return args
+}
+
+// StateDir returns the directory of the state and history files and whether
+// it was set by a flag or the environment
+func StateDir() (string, bool, error) {
+	if dir := firstSet(overrides.stateDir, os.Getenv(EnvStateDir)); dir != "" {
+		return dir, true, nil
+	}
+
+	// Linux keeps state apart from configuration, other systems have no such place
+	if runtime.GOOS == "linux" {
+		stateHome := os.Getenv("XDG_STATE_HOME")
+		if !filepath.IsAbs(stateHome) {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", false, fmt.Errorf("failed to get home dir: %w", err)
+			}
+			stateHome = filepath.Join(home, ".local", "state")
+		}
+		return filepath.Join(stateHome, "gts"), false, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get config dir: %w", err)
+	}
+	return filepath.Join(configDir, "gts"), false, nil
+}
+
+// ResolvePaths returns the files gts uses, from the --config and --state-dir
+// flags, GTS_CONFIG and GTS_STATE_DIR, or the defaults. It creates nothing,
+// directories are made when the config is first saved.
+func ResolvePaths() (Paths, error) {
+	configFile := firstSet(overrides.config, os.Getenv(EnvConfig))
+	if configFile == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return Paths{}, fmt.Errorf("failed to get config dir: %w", err)
+		}
+		configFile = filepath.Join(configDir, "gts", configFileName)
+	}
+
+	stateDir, overridden, err := StateDir()
+	if err != nil {
+		return Paths{}, err
+	}
+	paths := Paths{
+		Config:  configFile,
+		State:   filepath.Join(stateDir, stateFileName),
+		History: filepath.Join(stateDir, historyFileName),
+	}
+
+	// Files from before state moved are picked up, but never into a state
+	// directory chosen by hand, which would take them from their profile
+	if oldDir := filepath.Dir(configFile); !overridden && oldDir != stateDir {
+		paths.OldState = filepath.Join(oldDir, stateFileName)
+		paths.OldHistory = filepath.Join(oldDir, historyFileName)
+	}
+	return paths, nil
+}
+
+// firstSet returns the first non-empty value
+func firstSet(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// absolute makes path absolute, leaving it as is when that fails
+func absolute(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// Load loads the configuration from disk. It never writes, an expired job
+// is dropped in memory and leaves the files with the next save.
+func Load() (*Config, error) {
+	paths, err := ResolvePaths()
+	if err != nil {
+		return nil, err
+	}
+	return read(paths)
+}
+
+// read loads the config from the files at paths. Without a config file the
+// state file is the single file written before version 3.
+func read(paths Paths) (*Config, error) {
+	current, err := readFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *Config
+	if current.config == nil {
+		cfg, err = decodeLegacy(current.state)
+	} else {
+		cfg, err = decodeSplit(current)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg.files = current
+
+	// A legacy file still holds its history, which moves on the first save
+	if !cfg.legacy() {
+		history, saved, err := readHistory(paths.History, paths.OldHistory)
+		if err != nil {
+			return nil, err
+		}
+		cfg.saved = saved
+		cfg.History = cfg.Retention.Apply(history, time.Now())
+	}
+
+	// Fill in settings added after the file was written
+	if cfg.Settings.WarningMinutes == nil {
+		cfg.Settings.WarningMinutes = DefaultWarningMinutes()
+	}
+	if cfg.Settings.SnoozeMinutes <= 0 {
+		cfg.Settings.SnoozeMinutes = DefaultSnoozeMinutes
+	}
+	if cfg.Settings.GraceMinutes <= 0 {
+		cfg.Settings.GraceMinutes = DefaultGraceMinutes
+	}
+
+	// Check if active job has expired and clean it up
+	if cfg.ActiveJob != nil && cfg.ActiveJob.Expired(time.Now()) {
+		cfg.ActiveJob = nil
+	}
+	return cfg, nil
+}
+
+// readFiles reads the config and state file, a missing file is left nil. A
+// state file not moved to the state directory yet is read where it was.
+func readFiles(paths Paths) (files, error) {
+	var f files
+	var err error
+	if f.config, err = readFile(paths.Config); err != nil {
+		return files{}, fmt.Errorf("failed to read config: %w", err)
+	}
+	if f.state, err = readFile(paths.State); err != nil {
+		return files{}, fmt.Errorf("failed to read state: %w", err)
+	}
+	if f.state == nil && paths.OldState != "" {
+		if f.state, err = readFile(paths.OldState); err != nil {
+			return files{}, fmt.Errorf("failed to read state: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// readFile returns the contents of path, nil when it does not exist
+func readFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// decodeSplit decodes config.toml and state.json, upgrading config.toml
+// when it was written by an older version
+func decodeSplit(f files) (*Config, error) {
+	migrated, version, err := migrateSplit(f.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var file configFile
+	if _, err := toml.Decode(string(migrated), &file); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	cfg := &Config{
+		Version:   file.Version,
+		Presets:   file.Presets,
+		Schedules: file.Schedules,
+		Retention: file.History,
+		Settings:  file.Settings,
+	}
+	if version < CurrentVersion {
+		// As with a legacy file, the original is backed up on the first save
+		cfg.migratedFrom, cfg.original = version, f.config
+	}
+
+	if f.state != nil {
+		var state stateFile
+		if err := json.Unmarshal(f.state, &state); err != nil {
+			return nil, fmt.Errorf("failed to parse state: %w", err)
+		}
+		cfg.applyState(state)
+	}
+	return cfg, nil
+}
+
+// decodeLegacy decodes the single state file of version 2 and before, which
+// it upgrades. A state file of a later version means config.toml was
+// removed, the defaults take its place then.
+func decodeLegacy(data []byte) (*Config, error) {
+	if data == nil {
+		return DefaultConfig(), nil
+	}
+
+	migrated, version, err := migrate(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if version >= splitVersion {
+		var state stateFile
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("failed to parse state: %w", err)
+		}
+		cfg := DefaultConfig()
+		cfg.applyState(state)
+		return cfg, nil
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(migrated, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	// The original is backed up on the first save, reading must not write
+	cfg.migratedFrom, cfg.original = version, data
+	return &cfg, nil
+}
+
+// legacy reports whether c was read from the single state file of version 2
+// and before, which still holds the history
+func (c *Config) legacy() bool {
+	return c.migratedFrom != 0 && c.migratedFrom < splitVersion
+}
+
+// applyState takes the active job and schedule progress from state
+func (c *Config) applyState(state stateFile) {
+	if state.Version > c.Version {
+		c.Version = state.Version
+	}
+	c.ActiveJob = state.ActiveJob
+	for i := range c.Schedules {
+		c.Schedules[i].LastArmed = state.LastArmed[c.Schedules[i].ID]
+	}
+}
+
+// encode renders config.toml and state.json for c
+func (c *Config) encode() (files, error) {
+	var buf bytes.Buffer
+	buf.WriteString(configHeader)
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	err := enc.Encode(configFile{
+		Version:   CurrentVersion,
+		History:   c.Retention,
+		Settings:  c.Settings,
+		Presets:   c.Presets,
+		Schedules: c.Schedules,
+	})
+	if err != nil {
+		return files{}, fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	state := stateFile{Version: CurrentVersion, ActiveJob: c.ActiveJob}
+	for _, s := range c.Schedules {
+		if !s.LastArmed.IsZero() {
+			if state.LastArmed == nil {
+				state.LastArmed = map[string]time.Time{}
+			}
+			state.LastArmed[s.ID] = s.LastArmed
+		}
+	}
+	stateData, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return files{}, fmt.Errorf("failed to marshal state: %w", err)
+	}
+	return files{config: buf.Bytes(), state: stateData}, nil
+}
+
+// Save saves the configuration to disk. It fails with ErrModified when
+// another instance wrote the files since c was loaded, and with a
+// VersionError when a newer gts wrote them.
+func (c *Config) Save() error {
+	unlock, err := c.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	paths := c.lock.paths
+	next, err := c.encode()
+	if err != nil {
+		return err
+	}
+
+	if c.migratedFrom != 0 {
+		// The backup stays next to the file it was read from
+		source := paths.Config
+		if c.legacy() {
+			source = paths.State
+			if paths.OldState != "" && !exists(source) {
+				source = paths.OldState
+			}
+		}
+		if err := c.backup(source); err != nil {
+			return err
+		}
+	}
+
+	// History goes first and the state last, a crash in between leaves
+	// files that still load: history records are applied by ID, and a
+	// legacy state file next to config.toml only adds its active job
+	if err := moveHistory(paths); err != nil {
+		return err
+	}
+	c.History = c.Retention.Apply(c.History, time.Now())
+	if err := c.appendHistory(paths.History); err != nil {
+		return err
+	}
+	if len(c.saved) > len(c.History) {
+		// The file holds entries the retention dropped
+		if err := c.compactHistory(paths.History); err != nil {
+			return err
+		}
+	}
+
+	// The TUI saves on every keystroke, leave files alone when nothing changed
+	if !bytes.Equal(next.config, c.files.config) {
+		if err := writeAtomic(paths.Config, next.config); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+		c.files.config = next.config
+	}
+	if !bytes.Equal(next.state, c.files.state) || !exists(paths.State) {
+		if err := writeAtomic(paths.State, next.state); err != nil {
+			return fmt.Errorf("failed to write state: %w", err)
+		}
+		c.files.state = next.state
+	}
+	if paths.OldState != "" {
+		// The state moved, its old file would be read again otherwise
+		for _, old := range []string{paths.OldState, paths.OldState + ".lock"} {
+			if err := os.Remove(old); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove old state: %w", err)
+			}
+		}
+	}
+
+	c.Version = CurrentVersion
+	c.migratedFrom, c.original = 0, nil
+	return nil
+}
+
+// moveHistory moves a history file that has not moved to the state
+// directory yet, before records are appended to the new one
+func moveHistory(paths Paths) error {
+	if paths.OldHistory == "" || exists(paths.History) || !exists(paths.OldHistory) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(paths.History), 0755); err != nil {
+		return fmt.Errorf("failed to create state dir: %w", err)
+	}
+	if err := os.Rename(paths.OldHistory, paths.History); err == nil {
+		return nil
+	}
+
+	// The directories may be on different file systems
+	data, err := os.ReadFile(paths.OldHistory)
+	if err != nil {
+		return fmt.Errorf("failed to move history: %w", err)
+	}
+	if err := writeAtomic(paths.History, data); err != nil {
+		return fmt.Errorf("failed to move history: %w", err)
+	}
+	return os.Remove(paths.OldHistory)
+}
+
+// exists reports whether there is a file at path
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// writeAtomic replaces the file at path with data, so a crash leaves either
+// the old or the new file behind but never a partly written one
+func writeAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Make the rename itself durable, directories cannot be synced on Windows
+	syncDir(dir)
+	return nil
+}
+
+// syncDir flushes the entries of dir, errors are ignored where unsupported
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+}
+
+// checksum identifies the contents of the config and state file
+func (f files) checksum() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d:", len(f.config))
+	h.Write(f.config)
+	fmt.Fprintf(h, "%d:", len(f.state))
+	h.Write(f.state)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,156 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/scheduler"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+)
+
+// requestTimeout bounds a single request to the daemon
+const requestTimeout = 10 * time.Second
+
+// Client talks to a running daemon over its control socket
+type Client struct {
+	http *http.Client
+}
+
+// Dial connects to the daemon, failing if none is running
+func Dial() (*Client, error) {
+	path, err := SocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("gts daemon is not running: %w", err)
+	}
+	conn.Close()
+
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	return &Client{
+		http: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", path)
+				},
+			},
+		},
+	}, nil
+}
+
+// Connect returns a client for the running daemon, or a scheduler that
+// uses the OS shutdown command when no daemon is running
+func Connect(cfg *config.Config) scheduler.Controller {
+	if client, err := Dial(); err == nil {
+		return client
+	}
+	return scheduler.New(cfg, shutdown.NewExecutor())
+}
+
+// StartAt schedules a power action at an absolute time
+func (c *Client) StartAt(endTime time.Time, action shutdown.Action, dryRun bool) error {
+	req := StartRequest{
+		EndTime: endTime,
+		Action:  string(action),
+		DryRun:  dryRun,
+	}
+	return c.do(http.MethodPost, "/v1/start", req, nil)
+}
+
+// Arm creates a job that counts down for countdown once trigger is met
+func (c *Client) Arm(trigger config.Trigger, countdown time.Duration, action shutdown.Action, dryRun bool) error {
+	req := ArmRequest{
+		Trigger:          trigger,
+		CountdownSeconds: int(countdown / time.Second),
+		Action:           string(action),
+		DryRun:           dryRun,
+	}
+	return c.do(http.MethodPost, "/v1/arm", req, nil)
+}
+
+// Extend moves the end time of the active job
+func (c *Client) Extend(delta time.Duration) error {
+	req := ExtendRequest{
+		Seconds: int(delta / time.Second),
+	}
+	return c.do(http.MethodPost, "/v1/extend", req, nil)
+}
+
+// Cancel cancels the active job
+func (c *Client) Cancel() error {
+	return c.do(http.MethodPost, "/v1/cancel", nil, nil)
+}
+
+// Status returns the daemon's status snapshot
+func (c *Client) Status() (scheduler.Status, error) {
+	var status scheduler.Status
+	err := c.do(http.MethodGet, "/v1/status", nil, &status)
+	return status, err
+}
+
+// do sends a request and decodes the response into out
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	// The host is ignored, requests always go to the socket
+	req, err := http.NewRequest(method, "http://gts"+path, &reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach gts daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
+			return fmt.Errorf("gts daemon returned %s", resp.Status)
+		}
+		return decodeError(apiErr)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode daemon response: %w", err)
+	}
+	return nil
+}
+
+// decodeError maps an API error back to the scheduler error it came from
+func decodeError(apiErr ErrorResponse) error {
+	switch apiErr.Code {
+	case CodeNoActiveJob:
+		return scheduler.ErrNoActiveJob
+	case CodeTimeInPast:
+		return scheduler.ErrTimeInPast
+	case CodeExecutorFailed:
+		return &scheduler.ExecutorError{Err: errors.New(apiErr.Error)}
+	case CodeForbidden:
+		if w := apiErr.Window; w != nil {
+			return &scheduler.WindowError{Window: w.Window, At: w.At, Until: w.Until}
+		}
+	}
+	return errors.New(apiErr.Error)
+}
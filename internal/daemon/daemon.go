@@ -0,0 +1,506 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/hooks"
+	"github.com/kaganyuksek/gotosleep/internal/i18n"
+	"github.com/kaganyuksek/gotosleep/internal/notify"
+	"github.com/kaganyuksek/gotosleep/internal/schedule"
+	"github.com/kaganyuksek/gotosleep/internal/scheduler"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+	"github.com/kaganyuksek/gotosleep/internal/trigger"
+)
+
+// checkInterval is how often the daemon compares the wall clock to the job end time.
+// Polling the wall clock instead of arming a timer keeps the countdown correct
+// across suspend and clock changes.
+const checkInterval = time.Second
+
+// scheduleRefreshInterval is how often the daemon rereads the recurring
+// schedules, which the UI and CLI edit in the state file
+const scheduleRefreshInterval = 10 * time.Second
+
+// Daemon owns the countdown of the active job and serves the control socket
+type Daemon struct {
+	executor shutdown.Executor
+	logger   *log.Logger
+
+	mu       sync.Mutex
+	job      *config.ActiveJob // authoritative active job, overlaid on every load
+	settings config.Settings   // settings as of the last load
+	warner   *notify.Warner    // nil when notifications are unavailable
+
+	hooksRunning bool
+	hookedStart  time.Time // start and end time of the job whose hooks last ran,
+	hookedEnd    time.Time // so a moved job runs them again before its new end
+
+	watcher      trigger.Watcher // samples the trigger of the active job
+	watchedStart time.Time       // start time of the job the watcher belongs to
+	reading      trigger.Reading // latest sample, reported in the status
+	watchErr     string          // last sampling error, logged once
+
+	schedules          []config.Schedule // recurring schedules as of the last refresh
+	schedulesRefreshed time.Time
+}
+
+// New creates a daemon that performs actions with the given executor
+func New(executor shutdown.Executor, logger *log.Logger) *Daemon {
+	return &Daemon{
+		executor: executor,
+		logger:   logger,
+	}
+}
+
+// Run serves the control socket and times the active job until ctx is done
+func (d *Daemon) Run(ctx context.Context) error {
+	if err := d.restore(); err != nil {
+		return err
+	}
+
+	notifier, err := notify.New(d.handleNotification)
+	if err != nil {
+		d.logger.Printf("warning notifications disabled: %v", err)
+	} else {
+		defer notifier.Close()
+		d.warner = notify.NewWarner(notifier)
+	}
+
+	path, err := SocketPath()
+	if err != nil {
+		return err
+	}
+
+	listener, err := listen(path, d.logger)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	server := &http.Server{Handler: d.handler()}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	d.logger.Printf("listening on %s", path)
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return server.Shutdown(shutdownCtx)
+		case err := <-serveErr:
+			return err
+		case now := <-ticker.C:
+			d.check(now)
+		}
+	}
+}
+
+// restore adopts the active job left in the state file by a previous daemon
+func (d *Daemon) restore() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	d.adoptSettings(cfg.Settings)
+
+	job := cfg.ActiveJob
+	if job == nil {
+		return nil
+	}
+
+	if job.Owner != config.OwnerDaemon {
+		// Timed by the OS shutdown command, report it but leave it alone
+		d.job = job
+		return nil
+	}
+
+	if job.Waiting {
+		d.job = job
+		d.logger.Printf("resumed %s job waiting for %s", shutdown.ActionOrDefault(job.Action), trigger.Describe(*job.Trigger))
+		return nil
+	}
+
+	if !time.Now().Before(job.EndTime) {
+		// The end time passed while no daemon was running, never act late
+		d.logger.Printf("job ending %s was missed while the daemon was stopped", job.EndTime.Format(time.RFC3339))
+		return scheduler.NewOwned(cfg, d.executor).Miss()
+	}
+
+	d.job = job
+	d.logger.Printf("resumed %s job ending %s", shutdown.ActionOrDefault(job.Action), job.EndTime.Format(time.RFC3339))
+	return nil
+}
+
+// check warns about the active job and fires it once its end time is reached
+func (d *Daemon) check(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.warner != nil {
+		if err := d.warner.Check(d.job, d.settings, now); err != nil {
+			d.logger.Printf("failed to send warning: %v", err)
+		}
+	}
+
+	if d.job == nil {
+		d.runSchedules(now)
+	}
+	if d.job == nil {
+		return
+	}
+
+	if t := d.job.Trigger; t != nil && d.job.Owner == config.OwnerDaemon && (d.job.Waiting || trigger.Rearms(*t) || trigger.Cancels(*t)) {
+		d.watch(now)
+		if d.job == nil || d.job.Waiting {
+			return
+		}
+	}
+
+	if d.job.Owner == config.OwnerDaemon && d.hooksDue(now) {
+		d.startHooks()
+	}
+
+	// The action waits for running hooks, they may still abort it
+	if d.hooksRunning || now.Before(d.job.EndTime) {
+		return
+	}
+
+	if d.job.Owner != config.OwnerDaemon {
+		// The OS performs the action itself, just forget the job
+		d.job = nil
+		return
+	}
+
+	action := shutdown.ActionOrDefault(d.job.Action)
+	d.logger.Printf("performing %s", action)
+
+	err := d.withScheduler(func(s *scheduler.Scheduler) error {
+		return s.Fire()
+	})
+	if err != nil {
+		d.logger.Printf("failed to perform %s: %v", action, err)
+	}
+}
+
+// runSchedules starts the job of a recurring schedule once the lead time
+// before its occurrence begins. Callers must hold d.mu.
+func (d *Daemon) runSchedules(now time.Time) {
+	if now.Sub(d.schedulesRefreshed) >= scheduleRefreshInterval {
+		cfg, err := config.Load()
+		if err != nil {
+			d.logger.Printf("failed to load schedules: %v", err)
+			return
+		}
+		d.adoptSettings(cfg.Settings)
+		d.schedules, d.schedulesRefreshed = cfg.Schedules, now
+	}
+
+	for i := range d.schedules {
+		sched := &d.schedules[i]
+		at, ok := schedule.Due(*sched, now)
+		if !ok {
+			continue
+		}
+
+		// The occurrence counts as started even on failure, never retry it every second
+		sched.LastArmed = at
+		err := d.withScheduler(func(s *scheduler.Scheduler) error {
+			return s.StartSchedule(sched.ID, at)
+		})
+		if err != nil {
+			d.logger.Printf("failed to start schedule %s: %v", sched.ID, err)
+			continue
+		}
+		d.logger.Printf("schedule %s: scheduled %s at %s", sched.ID, shutdown.ActionOrDefault(sched.Action), at.Format(time.RFC3339))
+		return
+	}
+}
+
+// watch samples the trigger of the active job and starts its countdown once
+// the condition is met. When the condition of a rearming trigger no longer
+// holds, the countdown stops again; a cancelling trigger drops the job
+// instead. Callers must hold d.mu.
+func (d *Daemon) watch(now time.Time) {
+	if d.watcher == nil || !d.watchedStart.Equal(d.job.StartTime) {
+		watcher, err := trigger.New(*d.job.Trigger)
+		if err != nil {
+			d.watchFailed(err)
+			return
+		}
+		d.watcher, d.watchedStart = watcher, d.job.StartTime
+	}
+
+	reading, err := d.watcher.Check(now)
+	if err != nil {
+		d.watchFailed(err)
+		return
+	}
+	d.reading, d.watchErr = reading, ""
+
+	switch {
+	case d.job.Waiting && reading.Met:
+		err = d.withScheduler(func(s *scheduler.Scheduler) error {
+			return s.BeginCountdown(now)
+		})
+		if err == nil {
+			d.logger.Printf("trigger met (%s), counting down to %s", reading.Detail, d.job.EndTime.Format(time.RFC3339))
+		}
+	case !d.job.Waiting && !reading.Met && trigger.Cancels(*d.job.Trigger):
+		err = d.withScheduler(func(s *scheduler.Scheduler) error {
+			return s.Cancel()
+		})
+		if err == nil {
+			d.logger.Printf("trigger no longer met (%s), cancelled job", reading.Detail)
+		}
+	case !d.job.Waiting && !reading.Met:
+		err = d.withScheduler(func(s *scheduler.Scheduler) error {
+			return s.Rearm()
+		})
+		if err == nil {
+			d.logger.Printf("trigger no longer met (%s), waiting again", reading.Detail)
+		}
+	}
+	if err != nil {
+		d.logger.Printf("failed to update triggered job: %v", err)
+	}
+}
+
+// watchFailed reports a sampling error in the status and logs it once.
+// Callers must hold d.mu.
+func (d *Daemon) watchFailed(err error) {
+	d.reading = trigger.Reading{Detail: err.Error()}
+	if err.Error() != d.watchErr {
+		d.watchErr = err.Error()
+		d.logger.Printf("failed to check trigger: %v", err)
+	}
+}
+
+// hooksDue reports whether the hooks of the active job should start now.
+// Callers must hold d.mu.
+func (d *Daemon) hooksDue(now time.Time) bool {
+	if len(d.settings.Hooks) == 0 || d.hooksRunning || d.job.Waiting {
+		return false
+	}
+	if d.job.StartTime.Equal(d.hookedStart) && d.job.EndTime.Equal(d.hookedEnd) {
+		return false
+	}
+	return !now.Before(d.job.EndTime.Add(-d.settings.HookLead()))
+}
+
+// startHooks runs the configured hooks for the active job in the background
+// and records their results once they finish. Callers must hold d.mu.
+func (d *Daemon) startHooks() {
+	hookList := append([]config.Hook(nil), d.settings.Hooks...)
+	startTime, dryRun := d.job.StartTime, d.job.DryRun
+
+	d.hooksRunning = true
+	d.hookedStart, d.hookedEnd = d.job.StartTime, d.job.EndTime
+
+	if dryRun {
+		for _, hook := range hookList {
+			d.logger.Printf("dry run: would run hook %s: %s", hook.Name, hook.Command)
+		}
+	} else {
+		d.logger.Printf("running %d hooks", len(hookList))
+	}
+
+	go func() {
+		results, aborted := hooks.Run(context.Background(), hookList, dryRun)
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.hooksRunning = false
+
+		for _, r := range results {
+			switch {
+			case r.Error != "":
+				d.logger.Printf("hook %s failed: %s", r.Name, r.Error)
+			case r.Failed():
+				d.logger.Printf("hook %s failed with exit code %d", r.Name, r.ExitCode)
+			}
+		}
+		if aborted {
+			d.logger.Printf("hook failed, aborting the action")
+		}
+
+		err := d.withScheduler(func(s *scheduler.Scheduler) error {
+			return s.FinishHooks(startTime, results, aborted)
+		})
+		if err != nil {
+			d.logger.Printf("failed to record hook results: %v", err)
+		}
+	}()
+}
+
+// withScheduler loads the latest config, overlays the daemon's job and runs fn.
+// The config is reloaded every time and locked until fn returns, so settings
+// and history written by other gts processes are preserved. Callers must
+// hold d.mu.
+func (d *Daemon) withScheduler(fn func(s *scheduler.Scheduler) error) error {
+	return config.Update(func(cfg *config.Config) error {
+		d.adoptSettings(cfg.Settings)
+		cfg.ActiveJob = d.job
+
+		err := fn(scheduler.NewOwned(cfg, d.executor))
+		d.job = cfg.ActiveJob
+		return err
+	})
+}
+
+// adoptSettings keeps the settings used for warnings, switching the
+// notification language when it was changed. Callers must hold d.mu.
+func (d *Daemon) adoptSettings(settings config.Settings) {
+	if settings.Language != d.settings.Language {
+		if err := i18n.SetLanguage(settings.Language); err != nil {
+			d.logger.Printf("failed to load language %s: %v", settings.Language, err)
+		}
+	}
+	d.settings = settings
+}
+
+// handleNotification routes a warning notification button to the active job
+func (d *Daemon) handleNotification(action notify.Action) {
+	switch action {
+	case notify.ActionSnooze:
+		d.mu.Lock()
+		snooze := time.Duration(d.settings.SnoozeMinutes) * time.Minute
+		d.mu.Unlock()
+
+		if _, err := d.extend(snooze); err != nil {
+			d.logger.Printf("failed to snooze job: %v", err)
+		}
+	case notify.ActionCancel:
+		// cancel logs the outcome itself
+		_, _ = d.cancel()
+	}
+}
+
+// status returns a snapshot of the daemon state
+func (d *Daemon) status() (scheduler.Status, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Reading the status must not save or wait for the lock, clients poll it
+	cfg, err := config.Load()
+	if err != nil {
+		return scheduler.Status{}, err
+	}
+	d.adoptSettings(cfg.Settings)
+	cfg.ActiveJob = d.job
+
+	status := scheduler.NewStatus(cfg, time.Now())
+	if status.Job != nil && status.Job.Trigger != nil {
+		status.Job.TriggerDetail = d.reading.Detail
+	}
+	return status, nil
+}
+
+// start schedules a new job owned by the daemon
+func (d *Daemon) start(endTime time.Time, action shutdown.Action, dryRun bool) (scheduler.Status, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var status scheduler.Status
+	err := d.withScheduler(func(s *scheduler.Scheduler) error {
+		if err := s.StartAt(endTime, action, dryRun); err != nil {
+			return err
+		}
+		status, _ = s.Status()
+		return nil
+	})
+	if err == nil {
+		d.logger.Printf("scheduled %s at %s", action, endTime.Format(time.RFC3339))
+	}
+	return status, err
+}
+
+// arm creates a daemon job that waits for trigger, sampled by watcher
+func (d *Daemon) arm(t config.Trigger, watcher trigger.Watcher, countdown time.Duration, action shutdown.Action, dryRun bool) (scheduler.Status, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var status scheduler.Status
+	err := d.withScheduler(func(s *scheduler.Scheduler) error {
+		if err := s.Arm(t, countdown, action, dryRun); err != nil {
+			return err
+		}
+		status, _ = s.Status()
+		return nil
+	})
+	if err == nil {
+		d.watcher, d.watchedStart = watcher, d.job.StartTime
+		d.reading, d.watchErr = trigger.Reading{}, ""
+		d.logger.Printf("armed %s waiting for %s", action, trigger.Describe(t))
+	}
+	return status, err
+}
+
+// cancel cancels the active job
+func (d *Daemon) cancel() (scheduler.Status, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var status scheduler.Status
+	err := d.withScheduler(func(s *scheduler.Scheduler) error {
+		if err := s.Cancel(); err != nil {
+			return err
+		}
+		status, _ = s.Status()
+		return nil
+	})
+	if err == nil {
+		d.logger.Printf("cancelled job")
+	} else if !errors.Is(err, scheduler.ErrNoActiveJob) {
+		d.logger.Printf("failed to cancel job: %v", err)
+	}
+	return status, err
+}
+
+// extend moves the end time of the active job by delta
+func (d *Daemon) extend(delta time.Duration) (scheduler.Status, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var status scheduler.Status
+	err := d.withScheduler(func(s *scheduler.Scheduler) error {
+		if err := s.Extend(delta); err != nil {
+			return err
+		}
+		status, _ = s.Status()
+		return nil
+	})
+	if err == nil && d.job.Waiting {
+		d.logger.Printf("changed countdown of waiting job by %s", delta)
+	} else if err == nil {
+		d.logger.Printf("moved job by %s to %s", delta, d.job.EndTime.Format(time.RFC3339))
+	}
+	return status, err
+}
+
+// history returns the history entries selected by q, newest first
+func (d *Daemon) history(q config.HistoryQuery) ([]config.History, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.QueryHistory(q), nil
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkOwner fails unless info describes a file owned by the current user
+func checkOwner(info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("owned by uid %d, not %d", stat.Uid, os.Getuid())
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+package daemon
+
+import "os"
+
+// checkOwner is a no-op on Windows, where the socket lives under the
+// user's private AppData directory
+func checkOwner(info os.FileInfo) error {
+	return nil
+}
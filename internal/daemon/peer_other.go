@@ -0,0 +1,11 @@
+//go:build !linux
+
+package daemon
+
+import "net"
+
+// verifyPeer relies on the private socket directory on platforms without
+// SO_PEERCRED
+func verifyPeer(conn net.Conn) error {
+	return nil
+}
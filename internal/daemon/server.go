@@ -0,0 +1,271 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/scheduler"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+	"github.com/kaganyuksek/gotosleep/internal/trigger"
+)
+
+// Error codes reported in ErrorResponse
+const (
+	CodeInvalidRequest = "invalid_request"
+	CodeNoActiveJob    = "no_active_job"
+	CodeTimeInPast     = "time_in_past"
+	CodeExecutorFailed = "executor_failed"
+	CodeForbidden      = "forbidden_window"
+	CodeInternal       = "internal"
+)
+
+// StartRequest is the body of POST /v1/start
+type StartRequest struct {
+	EndTime time.Time `json:"end_time,omitempty"` // absolute end time
+	Minutes int       `json:"minutes,omitempty"`  // relative duration, used when EndTime is zero
+	Action  string    `json:"action,omitempty"`   // defaults to poweroff
+	DryRun  bool      `json:"dry_run"`
+}
+
+// ArmRequest is the body of POST /v1/arm
+type ArmRequest struct {
+	Trigger          config.Trigger `json:"trigger"`
+	CountdownSeconds int            `json:"countdown_seconds"` // runs once the trigger is met
+	Action           string         `json:"action,omitempty"`  // defaults to poweroff
+	DryRun           bool           `json:"dry_run"`
+}
+
+// ExtendRequest is the body of POST /v1/extend
+type ExtendRequest struct {
+	Minutes int `json:"minutes,omitempty"` // negative to shorten
+	Seconds int `json:"seconds,omitempty"` // added to minutes
+}
+
+// ErrorResponse is returned with a non-2xx status when a request fails
+type ErrorResponse struct {
+	Error  string        `json:"error"`
+	Code   string        `json:"code"`
+	Window *WindowDetail `json:"window,omitempty"` // set with CodeForbidden
+}
+
+// WindowDetail tells which forbidden window refused an action
+type WindowDetail struct {
+	Window config.Window `json:"window"`
+	At     time.Time     `json:"at"`    // when the action would have run
+	Until  time.Time     `json:"until"` // end of the window
+}
+
+// handler returns the HTTP handler serving the control API
+func (d *Daemon) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/status", d.handleStatus)
+	mux.HandleFunc("/v1/start", d.handleStart)
+	mux.HandleFunc("/v1/arm", d.handleArm)
+	mux.HandleFunc("/v1/extend", d.handleExtend)
+	mux.HandleFunc("/v1/cancel", d.handleCancel)
+	mux.HandleFunc("/v1/history", d.handleHistory)
+	return mux
+}
+
+// handleStatus serves GET /v1/status
+func (d *Daemon) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	status, err := d.status()
+	writeResult(w, status, err)
+}
+
+// handleStart serves POST /v1/start
+func (d *Daemon) handleStart(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req StartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	action, err := shutdown.ParseAction(req.Action)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	endTime := req.EndTime
+	if endTime.IsZero() {
+		if req.Minutes <= 0 {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "either end_time or a positive minutes is required")
+			return
+		}
+		endTime = time.Now().Add(time.Duration(req.Minutes) * time.Minute)
+	}
+
+	status, err := d.start(endTime, action, req.DryRun)
+	writeResult(w, status, err)
+}
+
+// handleArm serves POST /v1/arm
+func (d *Daemon) handleArm(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req ArmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	action, err := shutdown.ParseAction(req.Action)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if req.CountdownSeconds <= 0 {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "countdown_seconds must be positive")
+		return
+	}
+
+	req.Trigger, err = trigger.Resolve(req.Trigger)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	watcher, err := trigger.New(req.Trigger)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	countdown := time.Duration(req.CountdownSeconds) * time.Second
+	status, err := d.arm(req.Trigger, watcher, countdown, action, req.DryRun)
+	writeResult(w, status, err)
+}
+
+// handleCancel serves POST /v1/cancel
+func (d *Daemon) handleCancel(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	status, err := d.cancel()
+	writeResult(w, status, err)
+}
+
+// handleExtend serves POST /v1/extend
+func (d *Daemon) handleExtend(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req ExtendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	delta := time.Duration(req.Minutes)*time.Minute + time.Duration(req.Seconds)*time.Second
+	if delta == 0 {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "minutes and seconds must not add up to zero")
+		return
+	}
+
+	status, err := d.extend(delta)
+	writeResult(w, status, err)
+}
+
+// handleHistory serves GET /v1/history?limit=N&status=S&action=A&os=O&since=T&until=T,
+// where status and action take comma-separated lists and times are RFC 3339
+func (d *Daemon) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	params := r.URL.Query()
+	q := config.HistoryQuery{OS: params.Get("os")}
+	if value := params.Get("limit"); value != "" {
+		var err error
+		q.Limit, err = strconv.Atoi(value)
+		if err != nil || q.Limit < 0 {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "limit must be a non-negative integer")
+			return
+		}
+	}
+	if value := params.Get("status"); value != "" {
+		q.Statuses = strings.Split(value, ",")
+	}
+	if value := params.Get("action"); value != "" {
+		q.Actions = strings.Split(value, ",")
+	}
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"since", &q.Since}, {"until", &q.Until}} {
+		if value := params.Get(p.name); value != "" {
+			var err error
+			*p.t, err = time.Parse(time.RFC3339, value)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, CodeInvalidRequest, p.name+" must be an RFC 3339 time")
+				return
+			}
+		}
+	}
+
+	entries, err := d.history(q)
+	writeResult(w, entries, err)
+}
+
+// allowMethod rejects requests using any method other than method
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, CodeInvalidRequest, fmt.Sprintf("method %s not allowed", r.Method))
+	return false
+}
+
+// writeResult writes v as JSON, or the error mapped to an API error code
+func writeResult(w http.ResponseWriter, v interface{}, err error) {
+	if err != nil {
+		var execErr *scheduler.ExecutorError
+		var windowErr *scheduler.WindowError
+		switch {
+		case errors.Is(err, scheduler.ErrNoActiveJob):
+			writeError(w, http.StatusConflict, CodeNoActiveJob, err.Error())
+		case errors.Is(err, scheduler.ErrTimeInPast):
+			writeError(w, http.StatusBadRequest, CodeTimeInPast, err.Error())
+		case errors.As(err, &execErr):
+			writeError(w, http.StatusBadGateway, CodeExecutorFailed, err.Error())
+		case errors.As(err, &windowErr):
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error:  err.Error(),
+				Code:   CodeForbidden,
+				Window: &WindowDetail{Window: windowErr.Window, At: windowErr.At, Until: windowErr.Until},
+			})
+		default:
+			writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// writeError writes an ErrorResponse with the given status
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, ErrorResponse{Error: message, Code: code})
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+// socketName is the file name of the daemon control socket
+const socketName = "gts.sock"
+
+// dialTimeout bounds how long clients wait for the daemon to answer a connect
+const dialTimeout = time.Second
+
+// ErrAlreadyRunning is returned when another daemon is serving the socket
+var ErrAlreadyRunning = errors.New("gts daemon is already running")
+
+// SocketPath returns the path of the daemon control socket
+func SocketPath() (string, error) {
+	// Profiles with their own state directory each have their own daemon
+	if stateDir, overridden, err := config.StateDir(); err != nil {
+		return "", err
+	} else if overridden {
+		return filepath.Join(stateDir, "run", socketName), nil
+	}
+
+	// Prefer the per-user runtime directory, it is private and cleared on logout
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "gts", socketName), nil
+	}
+
+	// The config dir is readable by others, so keep the socket in a private subdir
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config dir: %w", err)
+	}
+	return filepath.Join(configDir, "gts", "run", socketName), nil
+}
+
+// listen creates the control socket, replacing a stale socket left by a crash.
+// Only the current user can connect: the socket lives in a directory only they
+// can enter, the socket itself is 0600, and on Linux the peer credentials of
+// every connection are checked as well.
+func listen(path string, logger *log.Logger) (net.Listener, error) {
+	if err := ensurePrivateDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, dialTimeout); err == nil {
+			conn.Close()
+			return nil, ErrAlreadyRunning
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+
+	return &peerListener{Listener: listener, logger: logger}, nil
+}
+
+// ensurePrivateDir creates dir if needed and makes sure only the current user can use it
+func ensurePrivateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create socket dir: %w", err)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check socket dir: %w", err)
+	}
+	if err := checkOwner(info); err != nil {
+		return fmt.Errorf("refusing to use socket dir %s: %w", dir, err)
+	}
+
+	if info.Mode().Perm()&0077 != 0 {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return fmt.Errorf("failed to restrict socket dir permissions: %w", err)
+		}
+	}
+	return nil
+}
+
+// peerListener drops connections from processes of other users
+type peerListener struct {
+	net.Listener
+	logger *log.Logger
+}
+
+// Accept waits for the next connection from the current user
+func (l *peerListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyPeer(conn); err != nil {
+			l.logger.Printf("rejected connection: %v", err)
+			conn.Close()
+			continue
+		}
+		return conn, nil
+	}
+}
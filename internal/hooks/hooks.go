@@ -0,0 +1,107 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+// DefaultTimeout bounds hooks that do not set a timeout of their own
+const DefaultTimeout = 30 * time.Second
+
+// maxOutput caps the output of a hook kept in history
+const maxOutput = 4096
+
+// waitDelay is how long output pipes may stay open after a hook was killed,
+// so a background child of the hook cannot block the action
+const waitDelay = 2 * time.Second
+
+// Run runs hooks in order and reports whether the action must be aborted. It
+// stops at the first failed hook that aborts the action. In dry-run mode no
+// command is executed and every hook is recorded as it would run.
+func Run(ctx context.Context, hooks []config.Hook, dryRun bool) ([]config.HookResult, bool) {
+	results := make([]config.HookResult, 0, len(hooks))
+	for _, hook := range hooks {
+		if dryRun {
+			results = append(results, config.HookResult{
+				Name:      hook.Name,
+				Command:   hook.Command,
+				StartedAt: time.Now(),
+				DryRun:    true,
+			})
+			continue
+		}
+
+		result := runHook(ctx, hook)
+		results = append(results, result)
+		if result.Failed() && hook.AbortOnFailure {
+			return results, true
+		}
+	}
+	return results, false
+}
+
+// Timeout returns the timeout of hook
+func Timeout(hook config.Hook) time.Duration {
+	if hook.TimeoutSeconds <= 0 {
+		return DefaultTimeout
+	}
+	return time.Duration(hook.TimeoutSeconds) * time.Second
+}
+
+// runHook runs a single hook through the system shell
+func runHook(ctx context.Context, hook config.Hook) config.HookResult {
+	timeout := Timeout(hook)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, hook.Command)
+	killTree(cmd)
+	cmd.WaitDelay = waitDelay
+
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+
+	result := config.HookResult{
+		Name:       hook.Name,
+		Command:    hook.Command,
+		StartedAt:  start,
+		DurationMs: time.Since(start).Milliseconds(),
+		Output:     truncate(string(output)),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.ExitCode = -1
+		result.TimedOut = true
+		result.Error = fmt.Sprintf("timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		result.ExitCode = -1
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// shellCommand builds a command that runs line through the system shell
+func shellCommand(ctx context.Context, line string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", line)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", line)
+}
+
+// truncate keeps the last maxOutput bytes of output, where errors usually are
+func truncate(output string) string {
+	if len(output) <= maxOutput {
+		return output
+	}
+	return "…" + output[len(output)-maxOutput:]
+}
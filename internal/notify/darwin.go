@@ -0,0 +1,33 @@
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// DarwinNotifier shows notifications through Notification Center. Buttons are
+// not supported, the gts commands doing the same are added to the text instead.
+type DarwinNotifier struct{}
+
+// Notify shows msg with osascript, passing the text as arguments to avoid quoting
+func (n *DarwinNotifier) Notify(msg Notification) error {
+	cmd := exec.Command("osascript",
+		"-e", "on run argv",
+		"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+		"-e", "end run",
+		msg.Title, bodyWithCommands(msg))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to send notification: %w (output: %s)", err, string(output))
+	}
+	return nil
+}
+
+// Dismiss is a no-op, Notification Center removes notifications itself
+func (n *DarwinNotifier) Dismiss() error {
+	return nil
+}
+
+// Close is a no-op
+func (n *DarwinNotifier) Close() error {
+	return nil
+}
@@ -0,0 +1,205 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Well-known name, object path and interface of the freedesktop notification service
+const (
+	notificationsName = "org.freedesktop.Notifications"
+	notificationsPath = "/org/freedesktop/Notifications"
+)
+
+// Notification urgency levels from the freedesktop notification spec
+const (
+	urgencyNormal   byte = 1
+	urgencyCritical byte = 2
+)
+
+// Signal is a signal emitted by the notification service
+type Signal struct {
+	Name string // member name, e.g. ActionInvoked
+	Body []interface{}
+}
+
+// Bus is the part of a D-Bus session connection used by DBusNotifier, so the
+// notifier can run against a fake bus
+type Bus interface {
+	// Call invokes a method of the notification service and returns the reply body
+	Call(method string, args ...interface{}) ([]interface{}, error)
+	// Signals delivers the signals emitted by the notification service
+	Signals() <-chan Signal
+	Close() error
+}
+
+// DBusNotifier sends freedesktop notifications over D-Bus
+type DBusNotifier struct {
+	bus      Bus
+	onAction func(Action)
+
+	mu sync.Mutex
+	id uint32 // id of the notification on screen, 0 when none
+}
+
+// NewDBusNotifier creates a notifier on bus and routes clicked buttons to onAction
+func NewDBusNotifier(bus Bus, onAction func(Action)) *DBusNotifier {
+	n := &DBusNotifier{
+		bus:      bus,
+		onAction: onAction,
+	}
+	go n.listen()
+	return n
+}
+
+// Notify shows msg, replacing the previous notification so warnings do not pile up
+func (n *DBusNotifier) Notify(msg Notification) error {
+	actions := make([]string, 0, 2*len(msg.Buttons))
+	for _, b := range msg.Buttons {
+		actions = append(actions, string(b.Action), b.Label)
+	}
+
+	urgency := urgencyNormal
+	if msg.Urgent {
+		urgency = urgencyCritical
+	}
+	hints := map[string]interface{}{"urgency": urgency}
+
+	n.mu.Lock()
+	replaces := n.id
+	n.mu.Unlock()
+
+	reply, err := n.bus.Call("Notify", "gts", replaces, "system-shutdown", msg.Title, msg.Body, actions, hints, int32(-1))
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+
+	if len(reply) == 1 {
+		if id, ok := reply[0].(uint32); ok {
+			n.mu.Lock()
+			n.id = id
+			n.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// Dismiss closes the notification on screen
+func (n *DBusNotifier) Dismiss() error {
+	n.mu.Lock()
+	id := n.id
+	n.id = 0
+	n.mu.Unlock()
+
+	if id == 0 {
+		return nil
+	}
+	if _, err := n.bus.Call("CloseNotification", id); err != nil {
+		return fmt.Errorf("failed to close notification: %w", err)
+	}
+	return nil
+}
+
+// Close disconnects from the bus
+func (n *DBusNotifier) Close() error {
+	return n.bus.Close()
+}
+
+// listen routes button clicks on our notification to onAction until the bus closes
+func (n *DBusNotifier) listen() {
+	for sig := range n.bus.Signals() {
+		if len(sig.Body) == 0 {
+			continue
+		}
+		id, _ := sig.Body[0].(uint32)
+
+		n.mu.Lock()
+		ours := id != 0 && id == n.id
+		if ours && sig.Name == "NotificationClosed" {
+			n.id = 0
+		}
+		n.mu.Unlock()
+
+		if !ours || sig.Name != "ActionInvoked" || len(sig.Body) != 2 || n.onAction == nil {
+			continue
+		}
+		if key, ok := sig.Body[1].(string); ok {
+			// Run the handler apart so it may call back into the notifier
+			go n.onAction(Action(key))
+		}
+	}
+}
+
+// sessionBus is a Bus backed by the D-Bus session bus
+type sessionBus struct {
+	conn    *dbus.Conn
+	signals chan Signal
+}
+
+// DialSessionBus connects to the session bus and subscribes to notification signals
+func DialSessionBus() (Bus, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to session bus: %w", err)
+	}
+
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(notificationsPath),
+		dbus.WithMatchInterface(notificationsName),
+	); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to subscribe to notification signals: %w", err)
+	}
+
+	raw := make(chan *dbus.Signal, 16)
+	conn.Signal(raw)
+
+	b := &sessionBus{
+		conn:    conn,
+		signals: make(chan Signal, 16),
+	}
+	go func() {
+		// godbus closes raw when the connection is closed
+		defer close(b.signals)
+		for sig := range raw {
+			if !strings.HasPrefix(sig.Name, notificationsName+".") {
+				continue
+			}
+			b.signals <- Signal{
+				Name: strings.TrimPrefix(sig.Name, notificationsName+"."),
+				Body: sig.Body,
+			}
+		}
+	}()
+	return b, nil
+}
+
+// Call invokes a method of the notification service
+func (b *sessionBus) Call(method string, args ...interface{}) ([]interface{}, error) {
+	for i, arg := range args {
+		// a{sv} arguments need their values wrapped in variants
+		if m, ok := arg.(map[string]interface{}); ok {
+			variants := make(map[string]dbus.Variant, len(m))
+			for k, v := range m {
+				variants[k] = dbus.MakeVariant(v)
+			}
+			args[i] = variants
+		}
+	}
+
+	call := b.conn.Object(notificationsName, notificationsPath).Call(notificationsName+"."+method, 0, args...)
+	return call.Body, call.Err
+}
+
+// Signals delivers the signals emitted by the notification service
+func (b *sessionBus) Signals() <-chan Signal {
+	return b.signals
+}
+
+// Close disconnects from the session bus
+func (b *sessionBus) Close() error {
+	return b.conn.Close()
+}
@@ -0,0 +1,190 @@
+package notify
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+// fakeBus records calls to the notification service and lets tests emit its signals
+type fakeBus struct {
+	mu      sync.Mutex
+	calls   [][]interface{} // method name followed by the arguments
+	nextID  uint32
+	signals chan Signal
+}
+
+func newFakeBus() *fakeBus {
+	return &fakeBus{nextID: 7, signals: make(chan Signal)}
+}
+
+func (b *fakeBus) Call(method string, args ...interface{}) ([]interface{}, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = append(b.calls, append([]interface{}{method}, args...))
+	if method == "Notify" {
+		return []interface{}{b.nextID}, nil
+	}
+	return nil, nil
+}
+
+func (b *fakeBus) Signals() <-chan Signal {
+	return b.signals
+}
+
+func (b *fakeBus) Close() error {
+	close(b.signals)
+	return nil
+}
+
+func (b *fakeBus) lastCall() []interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.calls) == 0 {
+		return nil
+	}
+	return b.calls[len(b.calls)-1]
+}
+
+var testWarning = Notification{
+	Title: "Shutting down in 5m",
+	Body:  "Scheduled for 23:30",
+	Buttons: []Button{
+		{Action: ActionSnooze, Label: "Snooze 10m", Command: "gts extend 10m"},
+		{Action: ActionCancel, Label: "Cancel", Command: "gts cancel"},
+	},
+}
+
+func TestDBusNotifierNotify(t *testing.T) {
+	bus := newFakeBus()
+	n := NewDBusNotifier(bus, nil)
+	defer n.Close()
+
+	if err := n.Notify(testWarning); err != nil {
+		t.Fatal(err)
+	}
+	call := bus.lastCall()
+	if call[0] != "Notify" || call[2] != uint32(0) {
+		t.Fatalf("first call = %v, want a new Notify", call)
+	}
+	if actions := call[6]; !reflect.DeepEqual(actions, []string{"snooze", "Snooze 10m", "cancel", "Cancel"}) {
+		t.Errorf("actions = %v", actions)
+	}
+
+	// Later warnings replace the one on screen
+	urgent := testWarning
+	urgent.Urgent = true
+	if err := n.Notify(urgent); err != nil {
+		t.Fatal(err)
+	}
+	call = bus.lastCall()
+	if call[2] != uint32(7) {
+		t.Errorf("replaces id = %v, want 7", call[2])
+	}
+	if hints := call[7].(map[string]interface{}); hints["urgency"] != urgencyCritical {
+		t.Errorf("hints = %v, want critical urgency", hints)
+	}
+
+	if err := n.Dismiss(); err != nil {
+		t.Fatal(err)
+	}
+	if call := bus.lastCall(); !reflect.DeepEqual(call, []interface{}{"CloseNotification", uint32(7)}) {
+		t.Errorf("dismiss call = %v", call)
+	}
+}
+
+func TestDBusNotifierActions(t *testing.T) {
+	tests := []struct {
+		name   string
+		signal Signal
+		want   Action // empty when the signal must be ignored
+	}{
+		{name: "snooze", signal: Signal{Name: "ActionInvoked", Body: []interface{}{uint32(7), "snooze"}}, want: ActionSnooze},
+		{name: "cancel", signal: Signal{Name: "ActionInvoked", Body: []interface{}{uint32(7), "cancel"}}, want: ActionCancel},
+		{name: "other notification", signal: Signal{Name: "ActionInvoked", Body: []interface{}{uint32(8), "cancel"}}},
+		{name: "closed", signal: Signal{Name: "NotificationClosed", Body: []interface{}{uint32(7), uint32(2)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := newFakeBus()
+			actions := make(chan Action, 1)
+			n := NewDBusNotifier(bus, func(a Action) { actions <- a })
+			defer n.Close()
+
+			if err := n.Notify(testWarning); err != nil {
+				t.Fatal(err)
+			}
+			bus.signals <- tt.signal
+
+			select {
+			case got := <-actions:
+				if got != tt.want {
+					t.Errorf("action = %q, want %q", got, tt.want)
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.want != "" {
+					t.Errorf("no action, want %q", tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDBusNotifierClosedForgetsID(t *testing.T) {
+	bus := newFakeBus()
+	actions := make(chan Action, 1)
+	n := NewDBusNotifier(bus, func(a Action) { actions <- a })
+	defer n.Close()
+
+	if err := n.Notify(testWarning); err != nil {
+		t.Fatal(err)
+	}
+	bus.signals <- Signal{Name: "NotificationClosed", Body: []interface{}{uint32(7), uint32(2)}}
+	// The unbuffered channel returns once listen took the signal, the next
+	// one is only taken after the first was handled
+	bus.signals <- Signal{Name: "ActionInvoked", Body: []interface{}{uint32(7), "cancel"}}
+
+	select {
+	case got := <-actions:
+		t.Errorf("closed notification routed %q", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Nothing on screen, so dismissing has nothing to close
+	if err := n.Dismiss(); err != nil {
+		t.Fatal(err)
+	}
+	if call := bus.lastCall(); call[0] != "Notify" {
+		t.Errorf("last call = %v, want no CloseNotification", call)
+	}
+}
+
+func TestBodyWithCommands(t *testing.T) {
+	body := bodyWithCommands(testWarning)
+	want := "Scheduled for 23:30\nSnooze 10m: gts extend 10m · Cancel: gts cancel"
+	if body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+
+	plain := Notification{Body: "Scheduled for 23:30"}
+	if got := bodyWithCommands(plain); got != plain.Body {
+		t.Errorf("body without buttons = %q", got)
+	}
+}
+
+func TestWarningCommands(t *testing.T) {
+	job := &config.ActiveJob{EndTime: time.Now().Add(5 * time.Minute), Action: "poweroff"}
+	msg := warning(job, 5*time.Minute, 90, false)
+	var commands []string
+	for _, b := range msg.Buttons {
+		commands = append(commands, string(b.Action)+"="+b.Command)
+	}
+	if got := strings.Join(commands, ","); got != "snooze=gts extend 1h30m,cancel=gts cancel" {
+		t.Errorf("commands = %s", got)
+	}
+}
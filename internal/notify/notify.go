@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Action identifies a button offered on a warning notification
+type Action string
+
+// Notification buttons that route back to the active job
+const (
+	ActionSnooze Action = "snooze"
+	ActionCancel Action = "cancel"
+)
+
+// Button is an action offered on a notification
+type Button struct {
+	Action  Action
+	Label   string
+	Command string // gts command doing the same, shown where buttons are not supported
+}
+
+// Notification is a desktop notification warning about an upcoming power action
+type Notification struct {
+	Title   string
+	Body    string
+	Buttons []Button
+	Urgent  bool // last warning, kept on screen until dismissed
+}
+
+// Notifier shows desktop notifications
+type Notifier interface {
+	// Notify shows n, replacing the notification previously shown by this notifier
+	Notify(n Notification) error
+	// Dismiss removes the notification previously shown, if any
+	Dismiss() error
+	Close() error
+}
+
+// bodyWithCommands returns the body of msg followed by the gts commands of
+// its buttons, for notifiers that cannot show buttons
+func bodyWithCommands(msg Notification) string {
+	if len(msg.Buttons) == 0 {
+		return msg.Body
+	}
+	commands := make([]string, len(msg.Buttons))
+	for i, b := range msg.Buttons {
+		commands[i] = b.Label + ": " + b.Command
+	}
+	return msg.Body + "\n" + strings.Join(commands, " · ")
+}
+
+// New creates a notifier for the current OS. onAction is called when a
+// notification button is clicked, on platforms that support buttons.
+func New(onAction func(Action)) (Notifier, error) {
+	switch runtime.GOOS {
+	case "linux":
+		bus, err := DialSessionBus()
+		if err != nil {
+			return nil, err
+		}
+		return NewDBusNotifier(bus, onAction), nil
+	case "darwin":
+		return &DarwinNotifier{}, nil
+	case "windows":
+		return &WindowsNotifier{}, nil
+	default:
+		return nil, fmt.Errorf("notifications are not supported on %s", runtime.GOOS)
+	}
+}
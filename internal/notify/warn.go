@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/i18n"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// Warner sends a notification each time the active job crosses a warning threshold
+type Warner struct {
+	notifier Notifier
+	start    time.Time       // start time of the job being watched
+	end      time.Time       // end time of the job being watched
+	pending  []time.Duration // thresholds not warned about yet, largest first
+}
+
+// NewWarner creates a warner that sends its warnings through notifier
+func NewWarner(notifier Notifier) *Warner {
+	return &Warner{notifier: notifier}
+}
+
+// Check sends a warning if job crossed a threshold since the last call. A new,
+// extended or shortened job re-arms only the thresholds still ahead of it, so
+// starting a short timer does not send every warning at once.
+func (w *Warner) Check(job *config.ActiveJob, settings config.Settings, now time.Time) error {
+	if job == nil || job.Waiting {
+		if w.end.IsZero() {
+			return nil
+		}
+		w.start, w.end, w.pending = time.Time{}, time.Time{}, nil
+		return w.notifier.Dismiss()
+	}
+
+	remaining := job.EndTime.Sub(now)
+	if !job.StartTime.Equal(w.start) || !job.EndTime.Equal(w.end) {
+		moved := !w.end.IsZero()
+		w.start, w.end = job.StartTime, job.EndTime
+		w.arm(settings.WarningMinutes, remaining)
+		if moved {
+			if err := w.notifier.Dismiss(); err != nil {
+				return err
+			}
+		}
+	}
+
+	crossed := 0
+	for crossed < len(w.pending) && w.pending[crossed] >= remaining {
+		crossed++
+	}
+	if crossed == 0 || remaining <= 0 {
+		return nil
+	}
+	w.pending = w.pending[crossed:]
+
+	return w.notifier.Notify(warning(job, remaining, settings.SnoozeMinutes, len(w.pending) == 0))
+}
+
+// arm keeps the thresholds that lie before the end of the job, largest first
+func (w *Warner) arm(minutes []int, remaining time.Duration) {
+	w.pending = w.pending[:0]
+	for _, m := range minutes {
+		threshold := time.Duration(m) * time.Minute
+		if threshold > 0 && threshold < remaining {
+			w.pending = append(w.pending, threshold)
+		}
+	}
+	sort.Slice(w.pending, func(i, j int) bool { return w.pending[i] > w.pending[j] })
+}
+
+// warning builds the notification for job with remaining time left
+func warning(job *config.ActiveJob, remaining time.Duration, snoozeMinutes int, last bool) Notification {
+	action := shutdown.ActionOrDefault(job.Action)
+	minutes := int(math.Ceil(remaining.Minutes()))
+
+	body := fmt.Sprintf(i18n.T("notifications.body"), job.EndTime.Format("15:04"))
+	if job.DryRun {
+		body += "\n" + i18n.T("notifications.dry_run")
+	}
+
+	return Notification{
+		Title: titleFor(action) + " " + utils.FormatDuration(minutes),
+		Body:  body,
+		Buttons: []Button{
+			{
+				Action:  ActionSnooze,
+				Label:   fmt.Sprintf(i18n.T("notifications.snooze"), utils.FormatDuration(snoozeMinutes)),
+				Command: "gts extend " + utils.FormatDuration(snoozeMinutes),
+			},
+			{Action: ActionCancel, Label: i18n.T("notifications.cancel"), Command: "gts cancel"},
+		},
+		Urgent: last,
+	}
+}
+
+// titleFor returns the countdown title of action, e.g. "Shutting down in"
+func titleFor(action shutdown.Action) string {
+	if action == shutdown.ActionPowerOff {
+		return i18n.T("active.title")
+	}
+	return i18n.T("active.title_" + string(action))
+}
@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// WindowsNotifier shows notifications as tray balloon tips. Buttons are not
+// supported, the gts commands doing the same are added to the text instead.
+type WindowsNotifier struct{}
+
+// Notify shows msg from a hidden PowerShell process that removes its tray icon afterwards
+func (n *WindowsNotifier) Notify(msg Notification) error {
+	icon := "Info"
+	if msg.Urgent {
+		icon = "Warning"
+	}
+
+	script := fmt.Sprintf("Add-Type -AssemblyName System.Windows.Forms; "+
+		"$n = New-Object System.Windows.Forms.NotifyIcon; "+
+		"$n.Icon = [System.Drawing.SystemIcons]::%s; $n.Visible = $true; "+
+		"$n.ShowBalloonTip(10000, %s, %s, '%s'); Start-Sleep -Seconds 10; $n.Dispose()",
+		icon, psQuote(msg.Title), psQuote(bodyWithCommands(msg)), icon)
+
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-WindowStyle", "Hidden", "-Command", script)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// Dismiss is a no-op, balloon tips time out by themselves
+func (n *WindowsNotifier) Dismiss() error {
+	return nil
+}
+
+// Close is a no-op
+func (n *WindowsNotifier) Close() error {
+	return nil
+}
+
+// psQuote quotes s as a PowerShell single-quoted string
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
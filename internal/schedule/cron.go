@@ -0,0 +1,195 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// cronMacros maps the supported @-macros to their expressions
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// monthNames and weekdayNames are the names cron accepts in place of numbers
+var (
+	monthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	weekdayNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+)
+
+// cronSearchDays bounds the search for the next run, February 29th on a
+// given weekday can be 28 years away
+const cronSearchDays = 366 * 29
+
+// Cron is a parsed 5-field cron expression, each field a bit set of the
+// values it matches
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	// Like Vixie cron, a restricted day of month and day of week match
+	// either one, while a "*" in one of them leaves the other to decide
+	domAny, dowAny bool
+}
+
+// ParseCron parses a standard 5-field cron expression such as
+// "30 23 * * 1-5" or one of the macros @yearly, @monthly, @weekly, @daily
+// and @hourly. Fields accept *, numbers, names, ranges, lists and steps.
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+	if strings.HasPrefix(expr, "@") {
+		macro, ok := cronMacros[expr]
+		if !ok {
+			return nil, fmt.Errorf("unsupported cron macro: %s", expr)
+		}
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression needs 5 fields (minute hour day month weekday), got %d", len(fields))
+	}
+
+	var c Cron
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid minute: %w", err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid hour: %w", err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid day of month: %w", err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("invalid month: %w", err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7, weekdayNames); err != nil {
+		return nil, fmt.Errorf("invalid weekday: %w", err)
+	}
+
+	// 7 is another name for Sunday
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = strings.HasPrefix(fields[2], "*")
+	c.dowAny = strings.HasPrefix(fields[4], "*")
+	return &c, nil
+}
+
+// Next returns the first run after the given instant, in its location.
+// Wall times skipped by a DST jump run at the moment of the jump, and wall
+// times repeated when clocks fall back run once, at the first occurrence.
+func (c *Cron) Next(after time.Time) (time.Time, error) {
+	loc := after.Location()
+	y, m, d := after.Date()
+	for i := 0; i < cronSearchDays; i++ {
+		// Noon is clear of DST transitions, which happen at night
+		day := time.Date(y, m, d+i, 12, 0, 0, 0, loc)
+		if !c.matchDay(day) {
+			continue
+		}
+
+		for hour := 0; hour < 24; hour++ {
+			if c.hour&(1<<hour) == 0 {
+				continue
+			}
+			for minute := 0; minute < 60; minute++ {
+				if c.minute&(1<<minute) == 0 {
+					continue
+				}
+				at := utils.WallClock(day.Year(), day.Month(), day.Day(), hour, minute, loc)
+				if at.After(after) {
+					return at, nil
+				}
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("cron expression never matches")
+}
+
+// matchDay reports whether the expression runs on the date of day
+func (c *Cron) matchDay(day time.Time) bool {
+	if c.month&(1<<uint(day.Month())) == 0 {
+		return false
+	}
+
+	dom := c.dom&(1<<uint(day.Day())) != 0
+	dow := c.dow&(1<<uint(day.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// parseCronField parses one field into a bit set of the values in [min, max]
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		span, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step: %s", part)
+			}
+		}
+
+		var lo, hi int
+		if span == "*" {
+			lo, hi = min, max
+		} else {
+			first, last, isRange := strings.Cut(span, "-")
+			var err error
+			if lo, err = cronValue(first, names); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = cronValue(last, names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// "5/15" runs from 5 to the end of the range
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%s is out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// cronValue parses a number or a name
+func cronValue(text string, names map[string]int) (int, error) {
+	if v, ok := names[text]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value: %s", text)
+	}
+	return v, nil
+}
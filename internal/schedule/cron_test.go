@@ -0,0 +1,188 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+// mustLoad loads the time zone called name
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestCronNext(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2025, month, day, hour, minute, 0, 0, berlin)
+	}
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2025, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  []time.Time // successive runs
+	}{
+		{
+			// Clocks spring forward from 02:00 CET to 03:00 CEST on 30 March
+			name:  "spring forward gap runs at the jump",
+			expr:  "30 2 * * *",
+			after: at(time.March, 29, 12, 0),
+			want:  []time.Time{utc(time.March, 30, 1, 0), utc(time.March, 31, 0, 30)},
+		},
+		{
+			// Clocks fall back from 03:00 CEST to 02:00 CET on 26 October
+			name:  "fall back overlap runs once",
+			expr:  "30 2 * * *",
+			after: at(time.October, 25, 12, 0),
+			want:  []time.Time{utc(time.October, 26, 0, 30), utc(time.October, 27, 1, 30)},
+		},
+		{
+			name:  "day of month or day of week",
+			expr:  "0 0 1 * mon",
+			after: at(time.September, 20, 0, 0),
+			want:  []time.Time{at(time.September, 22, 0, 0), at(time.September, 29, 0, 0), at(time.October, 1, 0, 0), at(time.October, 6, 0, 0)},
+		},
+		{
+			name:  "day of month alone",
+			expr:  "0 0 1 * *",
+			after: at(time.September, 20, 0, 0),
+			want:  []time.Time{at(time.October, 1, 0, 0), at(time.November, 1, 0, 0)},
+		},
+		{
+			name:  "day of week range",
+			expr:  "0 22 * * 1-5",
+			after: at(time.January, 3, 23, 0), // Friday
+			want:  []time.Time{at(time.January, 6, 22, 0), at(time.January, 7, 22, 0)},
+		},
+		{
+			name:  "steps over ranges",
+			expr:  "*/20 9-10 * * *",
+			after: at(time.January, 1, 10, 30),
+			want:  []time.Time{at(time.January, 1, 10, 40), at(time.January, 2, 9, 0), at(time.January, 2, 9, 20)},
+		},
+		{
+			name:  "step from a start value",
+			expr:  "5/15 1 * * *",
+			after: at(time.January, 1, 0, 0),
+			want:  []time.Time{at(time.January, 1, 1, 5), at(time.January, 1, 1, 20), at(time.January, 1, 1, 35), at(time.January, 1, 1, 50), at(time.January, 2, 1, 5)},
+		},
+		{
+			name:  "lists",
+			expr:  "0,30 23 * * *",
+			after: at(time.January, 1, 23, 0),
+			want:  []time.Time{at(time.January, 1, 23, 30), at(time.January, 2, 23, 0)},
+		},
+		{
+			name:  "month and day names",
+			expr:  "0 23 * JAN,dec Sat",
+			after: at(time.January, 25, 23, 30), // Saturday
+			want:  []time.Time{at(time.December, 6, 23, 0), at(time.December, 13, 23, 0)},
+		},
+		{
+			name:  "7 is Sunday",
+			expr:  "0 8 * * 7",
+			after: at(time.January, 1, 0, 0),
+			want:  []time.Time{at(time.January, 5, 8, 0)},
+		},
+		{
+			name:  "daily macro",
+			expr:  "@daily",
+			after: at(time.January, 1, 12, 0),
+			want:  []time.Time{at(time.January, 2, 0, 0), at(time.January, 3, 0, 0)},
+		},
+		{
+			name:  "weekly macro",
+			expr:  "@weekly",
+			after: at(time.January, 1, 12, 0),
+			want:  []time.Time{at(time.January, 5, 0, 0), at(time.January, 12, 0, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			after := tt.after
+			for i, want := range tt.want {
+				got, err := c.Next(after)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !got.Equal(want) {
+					t.Fatalf("run %d = %s, want %s", i+1, got.Format(time.RFC3339), want.In(berlin).Format(time.RFC3339))
+				}
+				if got.Location() != berlin {
+					t.Errorf("run %d is in %s, want Europe/Berlin", i+1, got.Location())
+				}
+				after = got
+			}
+		})
+	}
+}
+
+func TestCronTimezone(t *testing.T) {
+	s := config.Schedule{ID: "1", Cron: "0 23 * * *", Timezone: "America/New_York"}
+	after := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	got, err := Next(s, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 23:00 EST is 04:00 UTC the next day
+	if want := time.Date(2025, 1, 2, 4, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next() = %s, want %s", got, want)
+	}
+	if got.Location().String() != "America/New_York" {
+		t.Errorf("Next() is in %s, want America/New_York", got.Location())
+	}
+
+	s.Timezone = "Mars/Olympus_Mons"
+	if _, err := Next(s, after); err == nil {
+		t.Error("Next() accepted an unknown time zone")
+	}
+}
+
+func TestCronNeverMatches(t *testing.T) {
+	for _, expr := range []string{"0 0 31 2 *", "0 0 30 feb *", "0 0 31 apr,jun,sep,nov *"} {
+		c, err := ParseCron(expr)
+		if err != nil {
+			t.Fatalf("%q: %v", expr, err)
+		}
+		if at, err := c.Next(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+			t.Errorf("%q runs at %s", expr, at)
+		}
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+		"@reboot",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded", expr)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	// Time zones of schedules must resolve on systems without a zoneinfo database
+	_ "time/tzdata"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// dayNames maps the accepted day names to weekdays
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// ParseDays parses the days a schedule runs on
+// Supported formats:
+// - "daily", "weekdays", "weekends"
+// - "mon,wed,fri" -> Monday, Wednesday and Friday
+// - "mon-fri", "fri-mon" -> ranges, wrapping over the weekend
+func ParseDays(input string) ([]time.Weekday, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	switch input {
+	case "daily", "everyday", "every day":
+		return []time.Weekday{0, 1, 2, 3, 4, 5, 6}, nil
+	case "weekdays":
+		return []time.Weekday{1, 2, 3, 4, 5}, nil
+	case "weekends":
+		return []time.Weekday{0, 6}, nil
+	}
+
+	seen := map[time.Weekday]bool{}
+	for _, part := range strings.Split(input, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		first, ok := dayNames[strings.TrimSpace(from)]
+		if !ok {
+			return nil, fmt.Errorf("invalid day: %s", part)
+		}
+		last := first
+		if isRange {
+			if last, ok = dayNames[strings.TrimSpace(to)]; !ok {
+				return nil, fmt.Errorf("invalid day: %s", part)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			seen[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+
+	days := make([]time.Weekday, 0, len(seen))
+	for d := range seen {
+		days = append(days, d)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+	return days, nil
+}
+
+// FormatDays formats days the way ParseDays reads them, e.g. "mon-fri"
+func FormatDays(days []time.Weekday) string {
+	set := map[time.Weekday]bool{}
+	for _, d := range days {
+		set[d] = true
+	}
+	switch {
+	case len(set) == 7:
+		return "daily"
+	case len(set) == 5 && !set[time.Saturday] && !set[time.Sunday]:
+		return "mon-fri"
+	case len(set) == 2 && set[time.Saturday] && set[time.Sunday]:
+		return "weekends"
+	}
+
+	// Monday first, like a calendar week
+	var names []string
+	for _, d := range []time.Weekday{1, 2, 3, 4, 5, 6, 0} {
+		if set[d] {
+			names = append(names, strings.ToLower(d.String()[:3]))
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Lead returns how long before its time the job of s starts
+func Lead(s config.Schedule) time.Duration {
+	if s.LeadMinutes <= 0 {
+		return config.DefaultScheduleLeadMinutes * time.Minute
+	}
+	return time.Duration(s.LeadMinutes) * time.Minute
+}
+
+// Location returns the time zone s is evaluated in
+func Location(s config.Schedule) (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone: %s", s.Timezone)
+	}
+	return loc, nil
+}
+
+// Validate checks that s describes when it runs
+func Validate(s config.Schedule) error {
+	if _, err := Location(s); err != nil {
+		return err
+	}
+	if s.Cron != "" {
+		_, err := ParseCron(s.Cron)
+		return err
+	}
+	if len(s.Days) == 0 {
+		return fmt.Errorf("schedule has no days")
+	}
+	_, _, err := utils.ParseClock(s.Time)
+	return err
+}
+
+// Describe returns when s runs, e.g. "mon-fri 23:30" or "cron 0 1 * * 6"
+func Describe(s config.Schedule) string {
+	when := FormatDays(s.Days) + " " + s.Time
+	if s.Cron != "" {
+		when = "cron " + s.Cron
+	}
+	if s.Timezone != "" {
+		when += " " + s.Timezone
+	}
+	return when
+}
+
+// Next returns the first occurrence of s after the given instant, in the
+// time zone of s
+func Next(s config.Schedule, after time.Time) (time.Time, error) {
+	loc, err := Location(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	after = after.In(loc)
+
+	if s.Cron != "" {
+		c, err := ParseCron(s.Cron)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return c.Next(after)
+	}
+
+	hour, minute, err := utils.ParseClock(s.Time)
+	if err != nil {
+		return time.Time{}, err
+	}
+	days := map[time.Weekday]bool{}
+	for _, d := range s.Days {
+		days[d] = true
+	}
+	if len(days) == 0 {
+		return time.Time{}, fmt.Errorf("schedule %s has no days", s.ID)
+	}
+
+	y, m, d := after.Date()
+	for i := 0; i <= 7; i++ {
+		at := utils.WallClock(y, m, d+i, hour, minute, loc)
+		if days[at.Weekday()] && at.After(after) {
+			return at, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("schedule %s has no next occurrence", s.ID)
+}
+
+// Runs returns the next count occurrences of s after the given instant
+func Runs(s config.Schedule, after time.Time, count int) ([]time.Time, error) {
+	var runs []time.Time
+	for len(runs) < count {
+		at, err := Next(s, after)
+		if err != nil {
+			return runs, err
+		}
+		runs = append(runs, at)
+		after = at
+	}
+	return runs, nil
+}
+
+// Pending returns the next occurrence of s whose job has not been started
+// yet, skipping tonight's when it was already started and cancelled
+func Pending(s config.Schedule, now time.Time) (time.Time, error) {
+	at, err := Next(s, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if at.Equal(s.LastArmed) {
+		return Next(s, at)
+	}
+	return at, nil
+}
+
+// Due returns the occurrence whose job s should start now: the lead time
+// before it has begun and the job was not started before
+func Due(s config.Schedule, now time.Time) (time.Time, bool) {
+	if !s.Enabled {
+		return time.Time{}, false
+	}
+
+	at, err := Next(s, now)
+	if err != nil || at.Equal(s.LastArmed) || now.Before(at.Add(-Lead(s))) {
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+// Upcoming returns the enabled schedule that runs next and its occurrence
+func Upcoming(schedules []config.Schedule, now time.Time) (*config.Schedule, time.Time) {
+	var next *config.Schedule
+	var nextAt time.Time
+	for i := range schedules {
+		if !schedules[i].Enabled {
+			continue
+		}
+		at, err := Pending(schedules[i], now)
+		if err != nil {
+			continue
+		}
+		if next == nil || at.Before(nextAt) {
+			next, nextAt = &schedules[i], at
+		}
+	}
+	return next, nextAt
+}
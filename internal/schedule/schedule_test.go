@@ -0,0 +1,163 @@
+package schedule
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+func TestParseDays(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []time.Weekday
+	}{
+		{input: "daily", want: []time.Weekday{0, 1, 2, 3, 4, 5, 6}},
+		{input: "weekdays", want: []time.Weekday{1, 2, 3, 4, 5}},
+		{input: "Weekends", want: []time.Weekday{0, 6}},
+		{input: "mon,wed,fri", want: []time.Weekday{1, 3, 5}},
+		{input: "mon-fri", want: []time.Weekday{1, 2, 3, 4, 5}},
+		{input: "fri-mon", want: []time.Weekday{0, 1, 5, 6}},
+		{input: "sunday, tue-wed", want: []time.Weekday{0, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDays(tt.input)
+		if err != nil {
+			t.Errorf("ParseDays(%q): %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseDays(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+		if again, _ := ParseDays(FormatDays(got)); !reflect.DeepEqual(again, got) {
+			t.Errorf("FormatDays(%v) = %q does not parse back", got, FormatDays(got))
+		}
+	}
+
+	for _, input := range []string{"", "funday", "mon-", "mon,,fri"} {
+		if _, err := ParseDays(input); err == nil {
+			t.Errorf("ParseDays(%q) succeeded", input)
+		}
+	}
+}
+
+func TestScheduleAcrossMidnight(t *testing.T) {
+	loc := mustLoad(t, "Europe/Berlin")
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, time.January, day, hour, minute, 0, 0, loc)
+	}
+	// Weeknights at a quarter past midnight, 1 January 2025 is a Wednesday
+	s := config.Schedule{ID: "1", Days: []time.Weekday{1, 2, 3, 4, 5}, Time: "00:15", Timezone: "Europe/Berlin", LeadMinutes: 30, Enabled: true}
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		lastArmed time.Time
+		next      time.Time
+		pending   time.Time
+		due       bool
+	}{
+		{name: "before the lead", now: at(1, 23, 40), next: at(2, 0, 15), pending: at(2, 0, 15)},
+		{name: "lead started the day before", now: at(1, 23, 50), next: at(2, 0, 15), pending: at(2, 0, 15), due: true},
+		{name: "already started", now: at(1, 23, 50), lastArmed: at(2, 0, 15), next: at(2, 0, 15), pending: at(3, 0, 15)},
+		{name: "friday night skips the weekend", now: at(3, 23, 50), next: at(6, 0, 15), pending: at(6, 0, 15)},
+		{name: "sunday night", now: at(5, 23, 50), next: at(6, 0, 15), pending: at(6, 0, 15), due: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := s
+			s.LastArmed = tt.lastArmed
+			checkSchedule(t, s, tt.now, tt.next, tt.pending, tt.due)
+		})
+	}
+}
+
+func TestScheduleAcrossDST(t *testing.T) {
+	loc := mustLoad(t, "Europe/Berlin")
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2025, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	s := config.Schedule{ID: "1", Days: []time.Weekday{0, 1, 2, 3, 4, 5, 6}, Time: "02:30", Timezone: "Europe/Berlin", LeadMinutes: 30, Enabled: true}
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		lastArmed time.Time
+		next      time.Time
+		pending   time.Time
+		due       bool
+	}{
+		{
+			// 02:30 does not exist on 30 March, it runs at the jump to 03:00 CEST
+			name:    "spring forward",
+			now:     utc(time.March, 30, 0, 45), // 01:45 CET
+			next:    utc(time.March, 30, 1, 0),
+			pending: utc(time.March, 30, 1, 0),
+			due:     true,
+		},
+		{
+			name:      "spring forward already started",
+			now:       utc(time.March, 30, 0, 45),
+			lastArmed: utc(time.March, 30, 1, 0),
+			next:      utc(time.March, 30, 1, 0),
+			pending:   utc(time.March, 31, 0, 30), // 02:30 CEST
+		},
+		{
+			// 02:30 happens twice on 26 October, the first one counts
+			name:    "fall back",
+			now:     utc(time.October, 26, 0, 10), // 02:10 CEST
+			next:    utc(time.October, 26, 0, 30),
+			pending: utc(time.October, 26, 0, 30),
+			due:     true,
+		},
+		{
+			name:    "fall back repeated hour",
+			now:     utc(time.October, 26, 1, 10), // 02:10 CET
+			next:    utc(time.October, 27, 1, 30), // 02:30 CET
+			pending: utc(time.October, 27, 1, 30),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := s
+			s.LastArmed = tt.lastArmed
+			checkSchedule(t, s, tt.now.In(loc), tt.next, tt.pending, tt.due)
+		})
+	}
+}
+
+func TestDueDisabled(t *testing.T) {
+	s := config.Schedule{ID: "1", Days: []time.Weekday{0, 1, 2, 3, 4, 5, 6}, Time: "23:30"}
+	now := time.Date(2025, 1, 1, 23, 20, 0, 0, time.Local)
+	if at, due := Due(s, now); due {
+		t.Errorf("disabled schedule due at %s", at)
+	}
+}
+
+// checkSchedule compares Next, Pending and Due of s at now
+func checkSchedule(t *testing.T, s config.Schedule, now, next, pending time.Time, due bool) {
+	t.Helper()
+	got, err := Next(s, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(next) {
+		t.Errorf("Next() = %s, want %s", got, next.In(got.Location()))
+	}
+
+	if got, err = Pending(s, now); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(pending) {
+		t.Errorf("Pending() = %s, want %s", got, pending.In(got.Location()))
+	}
+
+	gotAt, gotDue := Due(s, now)
+	if gotDue != due || (due && !gotAt.Equal(next)) {
+		t.Errorf("Due() = %s, %v, want %s, %v", gotAt, gotDue, next, due)
+	}
+}
@@ -0,0 +1,167 @@
+package schedule
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// UnitPrefix starts the names of the systemd units written for schedules
+const UnitPrefix = "gts-schedule-"
+
+// UnitDir returns the directory of systemd user units
+func UnitDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config directory: %w", err)
+	}
+	return filepath.Join(dir, "systemd", "user"), nil
+}
+
+// OnCalendar returns the systemd calendar expression that fires when the
+// job of s should start, its lead time before the schedule's time. Cron
+// expressions cannot be shifted by the lead time, their timers check every
+// minute whether the job is due instead.
+func OnCalendar(s config.Schedule) (string, error) {
+	if s.Cron != "" {
+		if _, err := ParseCron(s.Cron); err != nil {
+			return "", err
+		}
+		return "minutely", nil
+	}
+
+	hour, minute, err := utils.ParseClock(s.Time)
+	if err != nil {
+		return "", err
+	}
+	if len(s.Days) == 0 {
+		return "", fmt.Errorf("schedule %s has no days", s.ID)
+	}
+
+	// A lead that reaches back past midnight starts the job on earlier days
+	start := hour*60 + minute - int(Lead(s).Minutes())
+	shift := 0
+	for start < 0 {
+		start += 24 * 60
+		shift++
+	}
+
+	var days []string
+	for _, d := range s.Days {
+		day := (d - time.Weekday(shift%7) + 7) % 7
+		days = append(days, day.String()[:3])
+	}
+	calendar := fmt.Sprintf("%s *-*-* %02d:%02d:00", strings.Join(days, ","), start/60, start%60)
+	if s.Timezone != "" {
+		calendar += " " + s.Timezone
+	}
+	return calendar, nil
+}
+
+// Units returns the timer and service unit that start the job of s by
+// running "gts schedule run" with the gts executable at path, and the
+// config and state locations this process uses
+func Units(s config.Schedule, path string) (timer, service string, err error) {
+	calendar, err := OnCalendar(s)
+	if err != nil {
+		return "", "", err
+	}
+
+	timer = fmt.Sprintf(`[Unit]
+Description=gts schedule %s (%s)
+
+[Timer]
+OnCalendar=%s
+Persistent=false
+
+[Install]
+WantedBy=timers.target
+`, s.ID, Describe(s), calendar)
+
+	service = fmt.Sprintf(`[Unit]
+Description=gts schedule %s
+
+[Service]
+Type=oneshot
+ExecStart=%s
+`, s.ID, execLine(path, s.ID))
+	return timer, service, nil
+}
+
+// WriteUnits replaces the schedule units in dir with units for the enabled
+// schedules and returns the names of the timers written and removed
+func WriteUnits(schedules []config.Schedule, dir, path string) (written, removed []string, err error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create unit directory: %w", err)
+	}
+
+	keep := map[string]bool{}
+	for _, s := range schedules {
+		if !s.Enabled {
+			continue
+		}
+		timer, service, err := Units(s, path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		name := UnitPrefix + s.ID
+		if err := os.WriteFile(filepath.Join(dir, name+".timer"), []byte(timer), 0644); err != nil {
+			return nil, nil, fmt.Errorf("failed to write timer unit: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".service"), []byte(service), 0644); err != nil {
+			return nil, nil, fmt.Errorf("failed to write service unit: %w", err)
+		}
+		keep[name] = true
+		written = append(written, name+".timer")
+	}
+
+	stale, err := filepath.Glob(filepath.Join(dir, UnitPrefix+"*.timer"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list units: %w", err)
+	}
+	for _, file := range stale {
+		name := strings.TrimSuffix(filepath.Base(file), ".timer")
+		if keep[name] {
+			continue
+		}
+		removed = append(removed, name+".timer")
+	}
+	return written, removed, nil
+}
+
+// RemoveUnits deletes the files of the given schedule timers and their services
+func RemoveUnits(dir string, timers []string) error {
+	for _, timer := range timers {
+		name := strings.TrimSuffix(timer, ".timer")
+		for _, file := range []string{name + ".timer", name + ".service"} {
+			if err := os.Remove(filepath.Join(dir, file)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", file, err)
+			}
+		}
+	}
+	return nil
+}
+
+// execLine returns the command line of the service that runs schedule id
+func execLine(path, id string) string {
+	args := append([]string{path}, config.OverrideArgs()...)
+	args = append(args, "schedule", "run", id)
+	for i, arg := range args {
+		args[i] = quoteExec(arg)
+	}
+	return strings.Join(args, " ")
+}
+
+// quoteExec quotes a path for an ExecStart line when it contains spaces
+func quoteExec(path string) string {
+	if strings.ContainsAny(path, " \t\"") {
+		return `"` + strings.ReplaceAll(path, `"`, `\"`) + `"`
+	}
+	return path
+}
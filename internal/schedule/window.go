@@ -0,0 +1,104 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// ValidateWindow checks the times of a forbidden window
+func ValidateWindow(w config.Window) error {
+	if _, _, err := utils.ParseClock(w.From); err != nil {
+		return fmt.Errorf("window %s: %w", w.Name, err)
+	}
+	if _, _, err := utils.ParseClock(w.To); err != nil {
+		return fmt.Errorf("window %s: %w", w.Name, err)
+	}
+	return nil
+}
+
+// DescribeWindow returns e.g. "backup 02:00-03:00" or "09:00-17:00 mon-fri"
+func DescribeWindow(w config.Window) string {
+	desc := w.From + "-" + w.To
+	if w.Name != "" {
+		desc = w.Name + " " + desc
+	}
+	if len(w.Days) > 0 {
+		desc += " " + FormatDays(w.Days)
+	}
+	return desc
+}
+
+// Blocking returns the window that at falls in and the end of that window.
+// Of overlapping windows, a refusing one wins over one that defers.
+func Blocking(windows []config.Window, at time.Time) (*config.Window, time.Time, bool) {
+	var found *config.Window
+	var until time.Time
+	for i := range windows {
+		end, ok := covers(windows[i], at)
+		if !ok {
+			continue
+		}
+		if found == nil || (found.Defer && !windows[i].Defer) || (found.Defer == windows[i].Defer && end.After(until)) {
+			found, until = &windows[i], end
+		}
+	}
+	return found, until, found != nil
+}
+
+// ErrNoClearTime is returned when the windows leave no time for an action
+var ErrNoClearTime = errors.New("forbidden windows cover the whole week, power actions can never run")
+
+// Clear returns the first instant at or after at that is outside of all
+// windows, the time an action that would fall inside them is pushed back to
+func Clear(windows []config.Window, at time.Time) (time.Time, error) {
+	// Back to back windows may push the action on several times, but
+	// windows repeat weekly so a week of them is all there can be
+	for i := 0; i <= 8*len(windows); i++ {
+		_, end, ok := Blocking(windows, at)
+		if !ok {
+			return at, nil
+		}
+		at = end
+	}
+	return time.Time{}, ErrNoClearTime
+}
+
+// covers reports whether at falls in an occurrence of w and when it ends.
+// Windows that span midnight started on the day before at.
+func covers(w config.Window, at time.Time) (time.Time, bool) {
+	fromHour, fromMinute, err := utils.ParseClock(w.From)
+	if err != nil {
+		return time.Time{}, false
+	}
+	toHour, toMinute, err := utils.ParseClock(w.To)
+	if err != nil {
+		return time.Time{}, false
+	}
+	overnight := toHour*60+toMinute <= fromHour*60+fromMinute
+
+	days := map[time.Weekday]bool{}
+	for _, d := range w.Days {
+		days[d] = true
+	}
+
+	y, m, d := at.Date()
+	for offset := -1; offset <= 0; offset++ {
+		start := utils.WallClock(y, m, d+offset, fromHour, fromMinute, at.Location())
+		if len(days) > 0 && !days[start.Weekday()] {
+			continue
+		}
+		endDay := d + offset
+		if overnight {
+			endDay++
+		}
+		end := utils.WallClock(y, m, endDay, toHour, toMinute, at.Location())
+		if !at.Before(start) && at.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
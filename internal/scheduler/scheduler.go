@@ -0,0 +1,500 @@
+package scheduler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/schedule"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// ErrNoActiveJob is returned when an operation needs an active job but none exists
+var ErrNoActiveJob = errors.New("no active shutdown job")
+
+// ErrTimeInPast is returned when a job would end before it starts
+var ErrTimeInPast = errors.New("scheduled time is in the past")
+
+// ErrNeedsDaemon is returned when a job needs the gts daemon to watch its trigger
+var ErrNeedsDaemon = errors.New("triggered jobs need the gts daemon, start it with: gts daemon")
+
+// ErrHooksNeedDaemon is returned when a job would skip its pre-action hooks
+// because no daemon runs them
+var ErrHooksNeedDaemon = errors.New("pre-action hooks need the gts daemon, start it with: gts daemon")
+
+// ErrNoSchedule is returned when a recurring schedule does not exist
+var ErrNoSchedule = errors.New("no such schedule")
+
+// ExecutorError wraps a failure reported by the shutdown executor
+type ExecutorError struct {
+	Err error
+}
+
+// Error returns the underlying executor error message
+func (e *ExecutorError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying executor error
+func (e *ExecutorError) Unwrap() error {
+	return e.Err
+}
+
+// Controller starts, cancels and reports shutdown jobs, either in process
+// or through the gts daemon
+type Controller interface {
+	StartAt(endTime time.Time, action shutdown.Action, dryRun bool) error
+	Arm(trigger config.Trigger, countdown time.Duration, action shutdown.Action, dryRun bool) error
+	Extend(delta time.Duration) error
+	Cancel() error
+	Status() (Status, error)
+}
+
+// Scheduler starts and cancels shutdown jobs and keeps the config in sync
+type Scheduler struct {
+	config   *config.Config
+	executor shutdown.Executor
+	owned    bool
+}
+
+// New creates a new scheduler that times jobs with the OS shutdown command
+func New(cfg *config.Config, executor shutdown.Executor) *Scheduler {
+	return &Scheduler{
+		config:   cfg,
+		executor: executor,
+	}
+}
+
+// NewOwned creates a scheduler whose jobs are timed by the caller, which
+// must call Fire once the end time of the active job is reached
+func NewOwned(cfg *config.Config, executor shutdown.Executor) *Scheduler {
+	return &Scheduler{
+		config:   cfg,
+		executor: executor,
+		owned:    true,
+	}
+}
+
+// Start schedules a power action in the given number of minutes
+func (s *Scheduler) Start(minutes int, action shutdown.Action, dryRun bool) error {
+	return s.StartAt(shutdown.CalculateJobInfo(minutes).EndTime, action, dryRun)
+}
+
+// StartAt schedules a power action at an absolute time
+func (s *Scheduler) StartAt(endTime time.Time, action shutdown.Action, dryRun bool) error {
+	// Timers handed to the OS cannot wait for hooks, a dry run runs none anyway
+	if !s.owned && !dryRun && len(s.config.Settings.Hooks) > 0 {
+		return ErrHooksNeedDaemon
+	}
+
+	unlock, err := s.config.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	endTime, err = CheckWindows(s.config.Settings.ForbiddenWindows, endTime)
+	if err != nil {
+		return err
+	}
+
+	// Calculate job info
+	jobInfo := shutdown.CalculateJobInfoAt(endTime)
+	minutes := shutdown.MinutesUntil(endTime, jobInfo.StartTime)
+	if minutes <= 0 {
+		return ErrTimeInPast
+	}
+
+	// Cancel any existing job first
+	if job := s.config.ActiveJob; job != nil {
+		_ = s.cancelJob(job)
+	}
+
+	// Schedule shutdown, owned jobs only render the command run at expiry
+	var command string
+	if s.owned {
+		command, err = s.executor.Execute(action, true)
+	} else {
+		command, err = s.executor.Schedule(action, endTime, dryRun)
+	}
+	if err != nil {
+		// Add to history as failed
+		h := config.History{
+			ID:              utils.GenerateID(),
+			CreatedAt:       time.Now(),
+			DurationSeconds: jobInfo.DurationSec,
+			ScheduledFor:    jobInfo.EndTime,
+			Status:          config.StatusFailed,
+			OS:              s.executor.GetOS(),
+			Command:         command,
+			Action:          string(action),
+		}
+		s.config.AddHistory(h)
+		s.config.Save()
+		return &ExecutorError{Err: err}
+	}
+
+	// Update config with active job
+	s.config.ActiveJob = &config.ActiveJob{
+		StartTime:   jobInfo.StartTime,
+		EndTime:     jobInfo.EndTime,
+		DurationSec: jobInfo.DurationSec,
+		Command:     command,
+		DryRun:      dryRun,
+		Action:      string(action),
+	}
+	if s.owned {
+		s.config.ActiveJob.Owner = config.OwnerDaemon
+	}
+
+	// Add to history
+	status := config.StatusOK
+	if dryRun {
+		status = config.StatusDryRun
+	}
+	h := config.History{
+		ID:              utils.GenerateID(),
+		CreatedAt:       jobInfo.StartTime,
+		DurationSeconds: jobInfo.DurationSec,
+		ScheduledFor:    jobInfo.EndTime,
+		Status:          status,
+		OS:              s.executor.GetOS(),
+		Command:         command,
+		Action:          string(action),
+	}
+	s.config.AddHistory(h)
+
+	// Save config
+	return s.config.Save()
+}
+
+// StartSchedule starts the job of the recurring schedule id ending at its
+// occurrence at. The occurrence is remembered even when starting fails, so a
+// cancelled or failed job is not started again the same night.
+func (s *Scheduler) StartSchedule(id string, at time.Time) error {
+	unlock, err := s.config.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sched := s.config.FindSchedule(id)
+	if sched == nil {
+		return ErrNoSchedule
+	}
+	sched.LastArmed = at
+
+	// Occurrences may be in the time zone of the schedule, jobs use local time
+	if err := s.StartAt(at.Local(), shutdown.ActionOrDefault(sched.Action), sched.DryRun); err != nil {
+		s.config.Save()
+		return err
+	}
+
+	if len(s.config.History) > 0 {
+		s.config.History[0].Schedule = id
+	}
+	return s.config.Save()
+}
+
+// Arm creates a job that waits for trigger and then counts down for countdown.
+// Only owned schedulers can arm jobs, the caller watches the trigger and calls
+// BeginCountdown and Rearm as its condition changes.
+func (s *Scheduler) Arm(trigger config.Trigger, countdown time.Duration, action shutdown.Action, dryRun bool) error {
+	unlock, err := s.config.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !s.owned {
+		return ErrNeedsDaemon
+	}
+	if countdown <= 0 {
+		return ErrTimeInPast
+	}
+
+	// Cancel any existing job first
+	if job := s.config.ActiveJob; job != nil {
+		_ = s.cancelJob(job)
+	}
+
+	now := time.Now()
+	durationSec := int(countdown.Round(time.Second).Seconds())
+	h := config.History{
+		ID:              utils.GenerateID(),
+		CreatedAt:       now,
+		DurationSeconds: durationSec,
+		Status:          config.StatusOK,
+		OS:              s.executor.GetOS(),
+		Action:          string(action),
+		Trigger:         &trigger,
+	}
+
+	// Render the command run at expiry
+	command, err := s.executor.Execute(action, true)
+	h.Command = command
+	if err != nil {
+		h.Status = config.StatusFailed
+		s.config.AddHistory(h)
+		s.config.Save()
+		return &ExecutorError{Err: err}
+	}
+
+	s.config.ActiveJob = &config.ActiveJob{
+		StartTime:   now,
+		DurationSec: durationSec,
+		Command:     command,
+		DryRun:      dryRun,
+		Action:      string(action),
+		Owner:       config.OwnerDaemon,
+		Trigger:     &trigger,
+		Waiting:     true,
+	}
+
+	if dryRun {
+		h.Status = config.StatusDryRun
+	}
+	s.config.AddHistory(h)
+
+	return s.config.Save()
+}
+
+// BeginCountdown starts the countdown of a waiting job whose trigger was met
+func (s *Scheduler) BeginCountdown(now time.Time) error {
+	unlock, err := s.config.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	job := s.config.ActiveJob
+	if job == nil || !job.Waiting {
+		return ErrNoActiveJob
+	}
+
+	// The job was accepted when it was armed, a forbidden window can only delay it
+	endTime, err := schedule.Clear(s.config.Settings.ForbiddenWindows, now.Add(time.Duration(job.DurationSec)*time.Second))
+	if err != nil {
+		return err
+	}
+	job.Waiting = false
+	job.EndTime = endTime
+	s.config.RescheduleHistory(job.EndTime)
+	return s.config.Save()
+}
+
+// Rearm stops the countdown of a triggered job whose condition no longer holds,
+// the job waits for its trigger again
+func (s *Scheduler) Rearm() error {
+	unlock, err := s.config.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	job := s.config.ActiveJob
+	if job == nil || job.Trigger == nil {
+		return ErrNoActiveJob
+	}
+
+	job.Waiting = true
+	job.EndTime = time.Time{}
+	s.config.RescheduleHistory(time.Time{})
+	return s.config.Save()
+}
+
+// Cancel cancels the active shutdown job
+func (s *Scheduler) Cancel() error {
+	unlock, err := s.config.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if s.config.ActiveJob == nil {
+		return ErrNoActiveJob
+	}
+
+	// Cancel the shutdown
+	err = s.cancelJob(s.config.ActiveJob)
+	if err != nil {
+		// Update history status to failed
+		s.config.UpdateHistoryStatus(config.StatusFailed)
+	} else {
+		// Update history status to cancelled
+		s.config.UpdateHistoryStatus(config.StatusCancelled)
+	}
+
+	// Clear active job
+	s.config.ActiveJob = nil
+
+	// Save config
+	if saveErr := s.config.Save(); saveErr != nil {
+		return saveErr
+	}
+
+	if err != nil {
+		return &ExecutorError{Err: err}
+	}
+	return nil
+}
+
+// Extend moves the end time of the active job by delta, a negative delta
+// shortens it. The start time is kept and the change is recorded on the
+// job's history entry instead of creating a new one.
+func (s *Scheduler) Extend(delta time.Duration) error {
+	unlock, err := s.config.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	job := s.config.ActiveJob
+	if job == nil {
+		return ErrNoActiveJob
+	}
+
+	now := time.Now()
+	if job.Waiting {
+		// Nothing is counting down yet, change the countdown that follows the trigger
+		if job.DurationSec+int(delta.Seconds()) <= 0 {
+			return ErrTimeInPast
+		}
+		job.DurationSec += int(delta.Seconds())
+		s.config.AdjustHistory(config.Adjustment{At: now, DeltaSeconds: int(delta.Seconds())}, job.Command)
+		return s.config.Save()
+	}
+
+	endTime, err := CheckWindows(s.config.Settings.ForbiddenWindows, job.EndTime.Add(delta))
+	if err != nil {
+		return err
+	}
+	delta = endTime.Sub(job.EndTime)
+	minutes := shutdown.MinutesUntil(endTime, now)
+	if minutes <= 0 {
+		return ErrTimeInPast
+	}
+
+	command := job.Command
+	if job.Owner != config.OwnerDaemon {
+		// Reschedule the OS timer, the old one must go first
+		if err := s.cancelJob(job); err != nil {
+			return &ExecutorError{Err: err}
+		}
+
+		command, err = s.executor.Schedule(shutdown.ActionOrDefault(job.Action), endTime, job.DryRun)
+		if err != nil {
+			// The old timer is gone, so the job is lost
+			s.config.ActiveJob = nil
+			s.config.UpdateHistoryStatus(config.StatusFailed)
+			s.config.Save()
+			return &ExecutorError{Err: err}
+		}
+	}
+
+	job.EndTime = endTime
+	job.DurationSec += int(delta.Seconds())
+	job.Command = command
+
+	s.config.AdjustHistory(config.Adjustment{
+		At:           now,
+		DeltaSeconds: int(delta.Seconds()),
+		ScheduledFor: endTime,
+	}, command)
+
+	return s.config.Save()
+}
+
+// Status returns a snapshot of the current job and latest history entry
+func (s *Scheduler) Status() (Status, error) {
+	return NewStatus(s.config, time.Now()), nil
+}
+
+// Fire performs the action of an owned job whose end time has been reached
+func (s *Scheduler) Fire() error {
+	unlock, err := s.config.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	job := s.config.ActiveJob
+	if job == nil {
+		return ErrNoActiveJob
+	}
+
+	// Clear the job before acting so a power-off does not leave it behind
+	s.config.ActiveJob = nil
+	if err := s.config.Save(); err != nil {
+		return err
+	}
+
+	if _, err := s.executor.Execute(shutdown.ActionOrDefault(job.Action), job.DryRun); err != nil {
+		s.config.UpdateHistoryStatus(config.StatusFailed)
+		s.config.Save()
+		return &ExecutorError{Err: err}
+	}
+
+	return nil
+}
+
+// FinishHooks records the hook results of the job started at startTime and,
+// when a hook aborted the action, cancels that job if it is still active
+func (s *Scheduler) FinishHooks(startTime time.Time, results []config.HookResult, aborted bool) error {
+	unlock, err := s.config.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.config.RecordHooks(startTime, results)
+
+	job := s.config.ActiveJob
+	if aborted && job != nil && job.StartTime.Equal(startTime) {
+		err := s.cancelJob(job)
+		s.config.ActiveJob = nil
+		s.config.UpdateHistoryStatus(config.StatusAborted)
+		if saveErr := s.config.Save(); saveErr != nil {
+			return saveErr
+		}
+		if err != nil {
+			return &ExecutorError{Err: err}
+		}
+		return nil
+	}
+
+	return s.config.Save()
+}
+
+// Miss clears an owned job whose end time passed while nobody was timing it
+func (s *Scheduler) Miss() error {
+	unlock, err := s.config.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if s.config.ActiveJob == nil {
+		return ErrNoActiveJob
+	}
+
+	s.config.ActiveJob = nil
+	s.config.UpdateHistoryStatus(config.StatusMissed)
+	return s.config.Save()
+}
+
+// cancelJob stops the OS timer of job, owned jobs are simply dropped
+func (s *Scheduler) cancelJob(job *config.ActiveJob) error {
+	if job.Owner == config.OwnerDaemon {
+		return nil
+	}
+
+	// Determine if it was a dry-run (older state files only record it in history)
+	dryRun := job.DryRun
+	if len(s.config.History) > 0 && s.config.History[0].Status == config.StatusDryRun {
+		dryRun = true
+	}
+
+	return s.executor.Cancel(shutdown.ActionOrDefault(job.Action), dryRun)
+}
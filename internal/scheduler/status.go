@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+)
+
+// StatusSchemaVersion is bumped whenever the Status JSON layout changes incompatibly
+const StatusSchemaVersion = 1
+
+// Status is a machine-readable snapshot of the scheduler state
+type Status struct {
+	SchemaVersion int             `json:"schema_version"`
+	Active        bool            `json:"active"`
+	Job           *JobStatus      `json:"job"`
+	LastHistory   *config.History `json:"last_history"`
+}
+
+// JobStatus describes the active job in a Status snapshot
+type JobStatus struct {
+	StartTime        time.Time `json:"start_time"`
+	EndTime          time.Time `json:"end_time"`
+	DurationSec      int       `json:"duration_sec"`
+	RemainingSeconds int       `json:"remaining_seconds"`
+	Command          string    `json:"command"`
+	DryRun           bool      `json:"dry_run"`
+	Action           string    `json:"action"`
+	Owner            string    `json:"owner"` // "os" or "daemon"
+
+	Trigger       *config.Trigger `json:"trigger,omitempty"`
+	Waiting       bool            `json:"waiting,omitempty"`        // end_time is unset while waiting
+	TriggerDetail string          `json:"trigger_detail,omitempty"` // latest reading, daemon only
+}
+
+// NewStatus builds a status snapshot of cfg at the given time
+func NewStatus(cfg *config.Config, now time.Time) Status {
+	status := Status{
+		SchemaVersion: StatusSchemaVersion,
+	}
+
+	if job := cfg.ActiveJob; job != nil {
+		remaining := int(job.EndTime.Sub(now).Seconds())
+		if remaining < 0 || job.Waiting {
+			remaining = 0
+		}
+		owner := "os"
+		if job.Owner == config.OwnerDaemon {
+			owner = config.OwnerDaemon
+		}
+		status.Active = true
+		status.Job = &JobStatus{
+			StartTime:        job.StartTime,
+			EndTime:          job.EndTime,
+			DurationSec:      job.DurationSec,
+			RemainingSeconds: remaining,
+			Command:          job.Command,
+			DryRun:           job.DryRun,
+			Action:           string(shutdown.ActionOrDefault(job.Action)),
+			Owner:            owner,
+			Trigger:          job.Trigger,
+			Waiting:          job.Waiting,
+		}
+	}
+
+	if len(cfg.History) > 0 {
+		last := cfg.History[0]
+		status.LastHistory = &last
+	}
+
+	return status
+}
@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/schedule"
+)
+
+// WindowError is returned when a power action would run inside a forbidden
+// window that refuses actions
+type WindowError struct {
+	Window config.Window
+	At     time.Time // when the action would have run
+	Until  time.Time // end of the window
+}
+
+// Error explains which window the action falls in
+func (e *WindowError) Error() string {
+	return fmt.Sprintf("%s falls in the forbidden window %s, power actions are not allowed until %s",
+		e.At.Format("15:04"), schedule.DescribeWindow(e.Window), e.Until.Format("15:04"))
+}
+
+// CheckWindows applies the forbidden windows to an action at the given time.
+// It returns the time the action may run at, pushed back past deferring
+// windows and any window it then lands in, or a WindowError when a window
+// refuses it.
+func CheckWindows(windows []config.Window, at time.Time) (time.Time, error) {
+	// A window that cannot be read must not let actions through unnoticed
+	for _, w := range windows {
+		if err := schedule.ValidateWindow(w); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	w, until, ok := schedule.Blocking(windows, at)
+	if !ok {
+		return at, nil
+	}
+	if !w.Defer {
+		return time.Time{}, &WindowError{Window: *w, At: at, Until: until}
+	}
+	return schedule.Clear(windows, at)
+}
@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/schedule"
+)
+
+func TestCheckWindows(t *testing.T) {
+	backup := config.Window{Name: "backup", From: "02:00", To: "03:00"}
+	work := config.Window{Name: "work", From: "09:00", To: "17:30", Defer: true}
+	evening := config.Window{Name: "evening", From: "17:30", To: "18:00", Defer: true}
+	allDay := config.Window{Name: "always", From: "00:00", To: "00:00", Defer: true}
+
+	// Wednesday, 1 January 2025
+	day := func(hour, minute int) time.Time {
+		return time.Date(2025, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name    string
+		windows []config.Window
+		at      time.Time
+		want    time.Time
+		refused bool
+		invalid bool
+		err     error
+	}{
+		{name: "no windows", at: day(23, 0), want: day(23, 0)},
+		{name: "outside", windows: []config.Window{backup, work}, at: day(23, 0), want: day(23, 0)},
+		{name: "refused", windows: []config.Window{backup, work}, at: day(2, 30), refused: true},
+		{name: "deferred", windows: []config.Window{backup, work}, at: day(12, 0), want: day(17, 30)},
+		{name: "back to back", windows: []config.Window{work, evening}, at: day(12, 0), want: day(18, 0)},
+		{name: "never clear", windows: []config.Window{allDay}, at: day(12, 0), err: schedule.ErrNoClearTime},
+		{name: "unreadable", windows: []config.Window{{From: "25:00", To: "03:00"}}, at: day(12, 0), invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CheckWindows(tt.windows, tt.at)
+			var windowErr *WindowError
+			switch {
+			case tt.refused:
+				if !errors.As(err, &windowErr) || windowErr.Window.Name != "backup" || !windowErr.Until.Equal(day(3, 0)) {
+					t.Errorf("err = %v, want a WindowError until 03:00", err)
+				}
+			case tt.err != nil:
+				if !errors.Is(err, tt.err) {
+					t.Errorf("err = %v, want %v", err, tt.err)
+				}
+			case tt.invalid:
+				if err == nil || errors.As(err, &windowErr) {
+					t.Errorf("err = %v, want the window rejected", err)
+				}
+			case err != nil:
+				t.Fatal(err)
+			case !got.Equal(tt.want):
+				t.Errorf("CheckWindows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
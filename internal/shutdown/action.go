@@ -0,0 +1,78 @@
+package shutdown
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Action represents the power action performed when a timer expires
+type Action string
+
+// Supported power actions
+const (
+	ActionPowerOff  Action = "poweroff"
+	ActionReboot    Action = "reboot"
+	ActionSuspend   Action = "suspend"
+	ActionHibernate Action = "hibernate"
+	ActionLogout    Action = "logout"
+	ActionLock      Action = "lock"
+)
+
+// Actions lists all supported actions in display order
+var Actions = []Action{
+	ActionPowerOff,
+	ActionReboot,
+	ActionSuspend,
+	ActionHibernate,
+	ActionLogout,
+	ActionLock,
+}
+
+// ParseAction parses an action name, an empty name means power-off
+func ParseAction(name string) (Action, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "poweroff", "shutdown", "off":
+		return ActionPowerOff, nil
+	case "reboot", "restart":
+		return ActionReboot, nil
+	case "suspend", "sleep":
+		return ActionSuspend, nil
+	case "hibernate":
+		return ActionHibernate, nil
+	case "logout", "logoff":
+		return ActionLogout, nil
+	case "lock":
+		return ActionLock, nil
+	}
+	return "", fmt.Errorf("unknown action: %s", name)
+}
+
+// ActionOrDefault converts a stored action name, falling back to power-off
+// for entries written before actions existed
+func ActionOrDefault(name string) Action {
+	action, err := ParseAction(name)
+	if err != nil {
+		return ActionPowerOff
+	}
+	return action
+}
+
+// Next returns the action following a in display order
+func (a Action) Next() Action {
+	for i, action := range Actions {
+		if action == a {
+			return Actions[(i+1)%len(Actions)]
+		}
+	}
+	return ActionPowerOff
+}
+
+// String returns the action name
+func (a Action) String() string {
+	return string(a)
+}
+
+// unsupportedAction returns the error reported for actions an OS cannot perform
+func unsupportedAction(action Action, os string) error {
+	return fmt.Errorf("action %s is not supported on %s", action, os)
+}
@@ -4,34 +4,56 @@ import (
 	"fmt"
 	"os/exec"
 	"strconv"
+	"time"
 )
 
 // DarwinExecutor implements Executor for macOS
 type DarwinExecutor struct{}
 
-// Schedule schedules a shutdown on macOS
-func (e *DarwinExecutor) Schedule(minutes int, dryRun bool) (string, error) {
-	command := fmt.Sprintf("sudo shutdown -h +%d", minutes)
+// Schedule schedules a power action on macOS
+func (e *DarwinExecutor) Schedule(action Action, at time.Time, dryRun bool) (string, error) {
+	args, err := e.scheduleArgs(action, MinutesUntil(at, time.Now()))
+	if err != nil {
+		return "", err
+	}
+	command := formatCommand(args)
 
 	if dryRun {
 		return command, nil
 	}
 
-	cmd := exec.Command("sudo", "shutdown", "-h", "+"+strconv.Itoa(minutes))
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return command, fmt.Errorf("failed to schedule shutdown (need sudo): %v, output: %s", err, string(output))
+	if e.usesShutdown(action) {
+		cmd := exec.Command(args[0], args[1:]...)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return command, fmt.Errorf("failed to schedule %s (need sudo): %v, output: %s", action, err, string(output))
+		}
+		return command, nil
+	}
+
+	if err := startDetached(args); err != nil {
+		return command, fmt.Errorf("failed to schedule %s: %v", action, err)
 	}
 
 	return command, nil
 }
 
-// Cancel cancels a scheduled shutdown on macOS
-func (e *DarwinExecutor) Cancel(dryRun bool) error {
+// Cancel cancels a scheduled power action on macOS
+func (e *DarwinExecutor) Cancel(action Action, dryRun bool) error {
 	if dryRun {
 		return nil
 	}
 
+	if !e.usesShutdown(action) {
+		// Stop the delayed launcher started by Schedule
+		cmd := exec.Command("pkill", "-f", delayMarker)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to cancel %s: %v, output: %s", action, err, string(output))
+		}
+		return nil
+	}
+
 	// Try shutdown -c first
 	cmd := exec.Command("sudo", "shutdown", "-c")
 	err := cmd.Run()
@@ -43,13 +65,68 @@ func (e *DarwinExecutor) Cancel(dryRun bool) error {
 	cmd = exec.Command("sudo", "killall", "shutdown")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("failed to cancel shutdown (need sudo): %v, output: %s", err, string(output))
+		return fmt.Errorf("failed to cancel %s (need sudo): %v, output: %s", action, err, string(output))
 	}
 
 	return nil
 }
 
+// Execute performs a power action immediately
+func (e *DarwinExecutor) Execute(action Action, dryRun bool) (string, error) {
+	args, err := e.actionArgs(action)
+	if err != nil {
+		return "", err
+	}
+	return runNow(action, args, dryRun)
+}
+
 // GetOS returns the OS name
 func (e *DarwinExecutor) GetOS() string {
 	return "darwin"
 }
+
+// usesShutdown reports whether action is scheduled through the shutdown command
+func (e *DarwinExecutor) usesShutdown(action Action) bool {
+	return action == ActionPowerOff || action == ActionReboot || action == ActionSuspend
+}
+
+// scheduleArgs returns the command that performs action after the given minutes
+func (e *DarwinExecutor) scheduleArgs(action Action, minutes int) ([]string, error) {
+	delay := "+" + strconv.Itoa(minutes)
+
+	switch action {
+	case ActionPowerOff:
+		return []string{"sudo", "shutdown", "-h", delay}, nil
+	case ActionReboot:
+		return []string{"sudo", "shutdown", "-r", delay}, nil
+	case ActionSuspend:
+		return []string{"sudo", "shutdown", "-s", delay}, nil
+	}
+
+	// Other actions have no native delay, so wait in a detached shell
+	args, err := e.actionArgs(action)
+	if err != nil {
+		return nil, err
+	}
+	script := fmt.Sprintf("sleep %d && %s", minutes*60, formatCommand(args))
+	return []string{"nohup", "sh", "-c", script, delayMarker}, nil
+}
+
+// actionArgs returns the command that performs action immediately
+func (e *DarwinExecutor) actionArgs(action Action) ([]string, error) {
+	switch action {
+	case ActionPowerOff:
+		return []string{"sudo", "shutdown", "-h", "now"}, nil
+	case ActionReboot:
+		return []string{"sudo", "shutdown", "-r", "now"}, nil
+	case ActionSuspend:
+		return []string{"pmset", "sleepnow"}, nil
+	case ActionLogout:
+		return []string{"osascript", "-e", `tell application "loginwindow" to «event aevtrlgo»`}, nil
+	case ActionLock:
+		return []string{"pmset", "displaysleepnow"}, nil
+	}
+
+	// macOS has no user-facing hibernate, only hibernatemode for sleep
+	return nil, unsupportedAction(action, e.GetOS())
+}
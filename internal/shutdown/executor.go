@@ -1,24 +1,51 @@
 package shutdown
 
 import (
+	"fmt"
+	"math"
+	"os/exec"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
-// Executor represents a shutdown command executor
+// delayMarker tags delayed action launchers so they can be found and cancelled
+const delayMarker = "gts-action"
+
+// Executor represents a shutdown command executor. Schedule runs the action
+// at the given time, executors whose OS timer counts in minutes round up.
 type Executor interface {
-	Schedule(minutes int, dryRun bool) (string, error)
-	Cancel(dryRun bool) error
+	Schedule(action Action, at time.Time, dryRun bool) (string, error)
+	Cancel(action Action, dryRun bool) error
+	Execute(action Action, dryRun bool) (string, error)
 	GetOS() string
 }
 
+// linuxExecutor is the executor picked for Linux, probing logind once
+var linuxExecutor struct {
+	once     sync.Once
+	executor Executor
+}
+
 // NewExecutor creates a new executor based on the current OS
 func NewExecutor() Executor {
 	switch runtime.GOOS {
 	case "windows":
 		return &WindowsExecutor{}
 	case "linux":
-		return &LinuxExecutor{}
+		// Prefer logind, whose calls polkit authorizes without sudo. Asking
+		// whether it runs is a D-Bus round trip, clients create executors
+		// on every sync so the answer is kept.
+		linuxExecutor.once.Do(func() {
+			if e, err := NewLogindExecutor(); err == nil {
+				linuxExecutor.executor = e
+			} else {
+				linuxExecutor.executor = &LinuxExecutor{}
+			}
+		})
+		return linuxExecutor.executor
 	case "darwin":
 		return &DarwinExecutor{}
 	default:
@@ -46,3 +73,58 @@ func CalculateJobInfo(minutes int) JobInfo {
 		DurationSec: minutes * 60,
 	}
 }
+
+// CalculateJobInfoAt calculates job timing information for an absolute end time
+func CalculateJobInfoAt(endTime time.Time) JobInfo {
+	now := time.Now()
+
+	return JobInfo{
+		StartTime:   now,
+		EndTime:     endTime,
+		DurationSec: int(endTime.Sub(now).Round(time.Second).Seconds()),
+	}
+}
+
+// MinutesUntil returns the whole minutes from now until t, rounded up so
+// minute-based OS timers never fire early
+func MinutesUntil(t, now time.Time) int {
+	return int(math.Ceil(t.Sub(now).Round(time.Second).Minutes()))
+}
+
+// formatCommand renders command arguments as a readable command line
+func formatCommand(args []string) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			arg = strconv.Quote(arg)
+		}
+		parts[i] = arg
+	}
+	return strings.Join(parts, " ")
+}
+
+// runNow runs the command described by args and waits for it to finish
+func runNow(action Action, args []string, dryRun bool) (string, error) {
+	command := formatCommand(args)
+
+	if dryRun {
+		return command, nil
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return command, fmt.Errorf("failed to %s: %v, output: %s", action, err, string(output))
+	}
+
+	return command, nil
+}
+
+// startDetached launches a command that keeps running after gts exits
+func startDetached(args []string) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
@@ -2,47 +2,120 @@ package shutdown
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"os/user"
 	"strconv"
+	"time"
 )
 
 // LinuxExecutor implements Executor for Linux
 type LinuxExecutor struct{}
 
-// Schedule schedules a shutdown on Linux
-func (e *LinuxExecutor) Schedule(minutes int, dryRun bool) (string, error) {
-	command := fmt.Sprintf("shutdown -h +%d", minutes)
+// Schedule schedules a power action on Linux
+func (e *LinuxExecutor) Schedule(action Action, at time.Time, dryRun bool) (string, error) {
+	args, err := e.scheduleArgs(action, MinutesUntil(at, time.Now()))
+	if err != nil {
+		return "", err
+	}
+	command := formatCommand(args)
 
 	if dryRun {
 		return command, nil
 	}
 
-	cmd := exec.Command("shutdown", "-h", "+"+strconv.Itoa(minutes))
+	cmd := exec.Command(args[0], args[1:]...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		// Check if it's a permission error
-		return command, fmt.Errorf("failed to schedule shutdown (may need sudo): %v, output: %s", err, string(output))
+		return command, fmt.Errorf("failed to schedule %s (may need sudo): %v, output: %s", action, err, string(output))
 	}
 
 	return command, nil
 }
 
-// Cancel cancels a scheduled shutdown on Linux
-func (e *LinuxExecutor) Cancel(dryRun bool) error {
+// Cancel cancels a scheduled power action on Linux
+func (e *LinuxExecutor) Cancel(action Action, dryRun bool) error {
 	if dryRun {
 		return nil
 	}
 
 	cmd := exec.Command("shutdown", "-c")
+	if action != ActionPowerOff && action != ActionReboot {
+		cmd = exec.Command("systemctl", "--user", "stop", delayMarker+".timer")
+	}
+
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("failed to cancel shutdown (may need sudo): %v, output: %s", err, string(output))
+		return fmt.Errorf("failed to cancel %s (may need sudo): %v, output: %s", action, err, string(output))
 	}
 
 	return nil
 }
 
+// Execute performs a power action immediately
+func (e *LinuxExecutor) Execute(action Action, dryRun bool) (string, error) {
+	args, err := e.actionArgs(action)
+	if err != nil {
+		return "", err
+	}
+	return runNow(action, args, dryRun)
+}
+
 // GetOS returns the OS name
 func (e *LinuxExecutor) GetOS() string {
 	return "linux"
 }
+
+// scheduleArgs returns the command that performs action after the given minutes
+func (e *LinuxExecutor) scheduleArgs(action Action, minutes int) ([]string, error) {
+	switch action {
+	case ActionPowerOff:
+		return []string{"shutdown", "-h", "+" + strconv.Itoa(minutes)}, nil
+	case ActionReboot:
+		return []string{"shutdown", "-r", "+" + strconv.Itoa(minutes)}, nil
+	}
+
+	// Other actions have no native delay, so run them from a transient user timer
+	args, err := e.actionArgs(action)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{
+		"systemd-run", "--user", "--collect",
+		"--unit=" + delayMarker,
+		"--on-active=" + strconv.Itoa(minutes) + "m",
+	}, args...), nil
+}
+
+// actionArgs returns the command that performs action immediately
+func (e *LinuxExecutor) actionArgs(action Action) ([]string, error) {
+	session := os.Getenv("XDG_SESSION_ID")
+
+	switch action {
+	case ActionPowerOff:
+		return []string{"systemctl", "poweroff"}, nil
+	case ActionReboot:
+		return []string{"systemctl", "reboot"}, nil
+	case ActionSuspend:
+		return []string{"systemctl", "suspend"}, nil
+	case ActionHibernate:
+		return []string{"systemctl", "hibernate"}, nil
+	case ActionLogout:
+		if session != "" {
+			return []string{"loginctl", "terminate-session", session}, nil
+		}
+		u, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine current user: %w", err)
+		}
+		return []string{"loginctl", "terminate-user", u.Username}, nil
+	case ActionLock:
+		if session != "" {
+			return []string{"loginctl", "lock-session", session}, nil
+		}
+		return []string{"loginctl", "lock-sessions"}, nil
+	}
+
+	return nil, unsupportedAction(action, e.GetOS())
+}
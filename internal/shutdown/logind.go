@@ -0,0 +1,161 @@
+package shutdown
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Bus name, object path and interface of the systemd-logind manager
+const (
+	logindName      = "org.freedesktop.login1"
+	logindPath      = "/org/freedesktop/login1"
+	logindInterface = "org.freedesktop.login1.Manager"
+)
+
+// logindMethods maps actions to the manager methods that perform them
+var logindMethods = map[Action]string{
+	ActionPowerOff:  "PowerOff",
+	ActionReboot:    "Reboot",
+	ActionSuspend:   "Suspend",
+	ActionHibernate: "Hibernate",
+}
+
+// ScheduledAction is an action the OS itself has scheduled
+type ScheduledAction struct {
+	Action Action
+	At     time.Time
+}
+
+// StateReader is implemented by executors that can ask the OS which action
+// it has scheduled, ok is false when nothing is
+type StateReader interface {
+	Scheduled() (s ScheduledAction, ok bool, err error)
+}
+
+// LogindExecutor implements Executor for Linux by calling systemd-logind over
+// D-Bus. Unlike shutdown(8) the calls are authorized by polkit, so users of
+// an active desktop session need no sudo. Actions logind cannot delay fall
+// back to the commands of LinuxExecutor.
+type LogindExecutor struct {
+	// Manager is the logind manager object, any bus object exporting the
+	// manager interface works, e.g. one on a private bus
+	Manager dbus.BusObject
+
+	commands LinuxExecutor
+}
+
+// NewLogindExecutor returns an executor for the logind manager on the system
+// bus, or an error when logind cannot be reached
+func NewLogindExecutor() (*LogindExecutor, error) {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to system bus: %w", err)
+	}
+	e := &LogindExecutor{Manager: conn.Object(logindName, logindPath)}
+
+	// A system without logind has nobody answering on its name
+	var can string
+	if err := e.Manager.Call(logindInterface+".CanPowerOff", 0).Store(&can); err != nil {
+		return nil, fmt.Errorf("failed to reach logind: %w", err)
+	}
+	if can == "na" {
+		return nil, fmt.Errorf("logind cannot power off this system")
+	}
+	return e, nil
+}
+
+// Schedule asks logind to power off or reboot at the given time
+func (e *LogindExecutor) Schedule(action Action, at time.Time, dryRun bool) (string, error) {
+	if action != ActionPowerOff && action != ActionReboot {
+		return e.commands.Schedule(action, at, dryRun)
+	}
+
+	// logind takes the time of the action in microseconds since the epoch
+	usec := uint64(at.UnixMicro())
+	command := busctl("ScheduleShutdown", "st", string(action), strconv.FormatUint(usec, 10))
+
+	if dryRun {
+		return command, nil
+	}
+
+	if err := e.Manager.Call(logindInterface+".ScheduleShutdown", 0, string(action), usec).Err; err != nil {
+		return command, fmt.Errorf("failed to schedule %s: %w", action, err)
+	}
+	return command, nil
+}
+
+// Cancel cancels the shutdown scheduled with logind
+func (e *LogindExecutor) Cancel(action Action, dryRun bool) error {
+	if action != ActionPowerOff && action != ActionReboot {
+		return e.commands.Cancel(action, dryRun)
+	}
+	if dryRun {
+		return nil
+	}
+
+	if err := e.Manager.Call(logindInterface+".CancelScheduledShutdown", 0).Err; err != nil {
+		return fmt.Errorf("failed to cancel %s: %w", action, err)
+	}
+	return nil
+}
+
+// Execute performs a power action immediately
+func (e *LogindExecutor) Execute(action Action, dryRun bool) (string, error) {
+	method, ok := logindMethods[action]
+	if !ok {
+		// Logging out and locking act on a session, which loginctl finds
+		return e.commands.Execute(action, dryRun)
+	}
+
+	// Actions run unattended, so polkit must not wait for a password
+	command := busctl(method, "b", "false")
+	if dryRun {
+		return command, nil
+	}
+
+	if err := e.Manager.Call(logindInterface+"."+method, 0, false).Err; err != nil {
+		return command, fmt.Errorf("failed to %s: %w", action, err)
+	}
+	return command, nil
+}
+
+// GetOS returns the OS name
+func (e *LogindExecutor) GetOS() string {
+	return "linux"
+}
+
+// Scheduled reads the shutdown logind has scheduled, whoever scheduled it
+func (e *LogindExecutor) Scheduled() (ScheduledAction, bool, error) {
+	prop, err := e.Manager.GetProperty(logindInterface + ".ScheduledShutdown")
+	if err != nil {
+		return ScheduledAction{}, false, fmt.Errorf("failed to read scheduled shutdown: %w", err)
+	}
+
+	// The property is a (st) struct of the shutdown type and its time
+	var scheduled struct {
+		Type string
+		Usec uint64
+	}
+	if err := dbus.Store([]interface{}{prop.Value()}, &scheduled); err != nil {
+		return ScheduledAction{}, false, fmt.Errorf("failed to read scheduled shutdown: %w", err)
+	}
+	if scheduled.Type == "" || scheduled.Usec == 0 {
+		return ScheduledAction{}, false, nil
+	}
+
+	// Types gts does not use, e.g. "halt", are kept as they are
+	return ScheduledAction{
+		Action: Action(scheduled.Type),
+		At:     time.UnixMicro(int64(scheduled.Usec)),
+	}, true, nil
+}
+
+// busctl renders a manager method call as the equivalent busctl command
+func busctl(method, signature string, args ...string) string {
+	return formatCommand(append([]string{
+		"busctl", "call", logindName, logindPath, logindInterface, method, signature,
+	}, args...))
+}
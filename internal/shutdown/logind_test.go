@@ -0,0 +1,209 @@
+package shutdown
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// fakeCall is a method call received by fakeManager
+type fakeCall struct {
+	method string
+	args   []interface{}
+}
+
+// fakeManager stands in for the logind manager object, recording calls and
+// answering the ScheduledShutdown property
+type fakeManager struct {
+	dbus.BusObject
+
+	calls     []fakeCall
+	err       error       // returned by every call
+	scheduled interface{} // value of the ScheduledShutdown property
+}
+
+func (m *fakeManager) Call(method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	m.calls = append(m.calls, fakeCall{method: method, args: args})
+	return &dbus.Call{Err: m.err}
+}
+
+func (m *fakeManager) GetProperty(p string) (dbus.Variant, error) {
+	if m.err != nil {
+		return dbus.Variant{}, m.err
+	}
+	if p != logindInterface+".ScheduledShutdown" {
+		return dbus.Variant{}, fmt.Errorf("unknown property %s", p)
+	}
+	return dbus.MakeVariant(m.scheduled), nil
+}
+
+func TestLogindSchedule(t *testing.T) {
+	tests := []struct {
+		action Action
+		kind   string
+	}{
+		{ActionPowerOff, "poweroff"},
+		{ActionReboot, "reboot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.action), func(t *testing.T) {
+			m := &fakeManager{}
+			e := &LogindExecutor{Manager: m}
+
+			// Not on a minute boundary, logind fires at the exact time
+			at := time.Now().Add(30*time.Minute + 15*time.Second)
+			command, err := e.Schedule(tt.action, at, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(m.calls) != 1 || m.calls[0].method != logindInterface+".ScheduleShutdown" {
+				t.Fatalf("calls = %v, want one ScheduleShutdown", m.calls)
+			}
+			args := m.calls[0].args
+			if len(args) != 2 || args[0] != tt.kind {
+				t.Fatalf("args = %v, want %q and a time", args, tt.kind)
+			}
+			usec, ok := args[1].(uint64)
+			if !ok {
+				t.Fatalf("time argument is %T, want uint64", args[1])
+			}
+			if want := uint64(at.UnixMicro()); usec != want {
+				t.Errorf("scheduled for %v, want %v", time.UnixMicro(int64(usec)), time.UnixMicro(int64(want)))
+			}
+			if !strings.HasPrefix(command, "busctl call "+logindName) || !strings.Contains(command, "ScheduleShutdown st "+tt.kind) {
+				t.Errorf("command = %q", command)
+			}
+		})
+	}
+}
+
+func TestLogindScheduleDryRun(t *testing.T) {
+	m := &fakeManager{}
+	e := &LogindExecutor{Manager: m}
+
+	command, err := e.Schedule(ActionPowerOff, time.Now().Add(10*time.Minute), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.calls) != 0 {
+		t.Errorf("dry run called %v", m.calls)
+	}
+	if !strings.Contains(command, "ScheduleShutdown") {
+		t.Errorf("command = %q", command)
+	}
+}
+
+func TestLogindCancel(t *testing.T) {
+	m := &fakeManager{}
+	e := &LogindExecutor{Manager: m}
+
+	if err := e.Cancel(ActionReboot, false); err != nil {
+		t.Fatal(err)
+	}
+	want := []fakeCall{{method: logindInterface + ".CancelScheduledShutdown"}}
+	if !reflect.DeepEqual(m.calls, want) {
+		t.Errorf("calls = %v, want %v", m.calls, want)
+	}
+
+	m.calls = nil
+	if err := e.Cancel(ActionPowerOff, true); err != nil || len(m.calls) != 0 {
+		t.Errorf("dry run: err = %v, calls = %v", err, m.calls)
+	}
+}
+
+func TestLogindExecute(t *testing.T) {
+	tests := []struct {
+		action Action
+		method string
+	}{
+		{ActionPowerOff, "PowerOff"},
+		{ActionReboot, "Reboot"},
+		{ActionSuspend, "Suspend"},
+		{ActionHibernate, "Hibernate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.action), func(t *testing.T) {
+			m := &fakeManager{}
+			e := &LogindExecutor{Manager: m}
+
+			command, err := e.Execute(tt.action, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Unattended actions must not wait for a polkit password prompt
+			want := []fakeCall{{method: logindInterface + "." + tt.method, args: []interface{}{false}}}
+			if !reflect.DeepEqual(m.calls, want) {
+				t.Errorf("calls = %v, want %v", m.calls, want)
+			}
+			if !strings.HasSuffix(command, tt.method+" b false") {
+				t.Errorf("command = %q", command)
+			}
+		})
+	}
+}
+
+func TestLogindExecuteError(t *testing.T) {
+	m := &fakeManager{err: errors.New("access denied")}
+	e := &LogindExecutor{Manager: m}
+
+	if _, err := e.Execute(ActionSuspend, false); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("err = %v, want the bus error", err)
+	}
+	if _, err := e.Schedule(ActionPowerOff, time.Now().Add(5*time.Minute), false); err == nil {
+		t.Error("Schedule succeeded despite the bus error")
+	}
+}
+
+func TestLogindScheduled(t *testing.T) {
+	at := time.Date(2025, 1, 1, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		scheduled interface{}
+		ok        bool
+		want      ScheduledAction
+	}{
+		{
+			name:      "poweroff",
+			scheduled: []interface{}{"poweroff", uint64(at.UnixMicro())},
+			ok:        true,
+			want:      ScheduledAction{Action: ActionPowerOff, At: at},
+		},
+		{
+			name:      "unknown type kept",
+			scheduled: []interface{}{"halt", uint64(at.UnixMicro())},
+			ok:        true,
+			want:      ScheduledAction{Action: "halt", At: at},
+		},
+		{name: "nothing scheduled", scheduled: []interface{}{"", uint64(0)}},
+		{name: "type without time", scheduled: []interface{}{"poweroff", uint64(0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &LogindExecutor{Manager: &fakeManager{scheduled: tt.scheduled}}
+
+			got, ok, err := e.Scheduled()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.ok || got.Action != tt.want.Action || !got.At.Equal(tt.want.At) {
+				t.Errorf("Scheduled() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestLogindScheduledError(t *testing.T) {
+	e := &LogindExecutor{Manager: &fakeManager{err: errors.New("no such object")}}
+	if _, _, err := e.Scheduled(); err == nil {
+		t.Error("Scheduled() succeeded despite the bus error")
+	}
+}
@@ -4,45 +4,123 @@ import (
 	"fmt"
 	"os/exec"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // WindowsExecutor implements Executor for Windows
 type WindowsExecutor struct{}
 
-// Schedule schedules a shutdown on Windows
-func (e *WindowsExecutor) Schedule(minutes int, dryRun bool) (string, error) {
-	seconds := minutes * 60
-	command := fmt.Sprintf("shutdown.exe /s /t %d", seconds)
+// Schedule schedules a power action on Windows
+func (e *WindowsExecutor) Schedule(action Action, at time.Time, dryRun bool) (string, error) {
+	args, err := e.scheduleArgs(action, MinutesUntil(at, time.Now()))
+	if err != nil {
+		return "", err
+	}
+	command := formatCommand(args)
 
 	if dryRun {
 		return command, nil
 	}
 
-	cmd := exec.Command("shutdown.exe", "/s", "/t", strconv.Itoa(seconds))
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return command, fmt.Errorf("failed to schedule shutdown: %v, output: %s", err, string(output))
+	if e.usesShutdown(action) {
+		cmd := exec.Command(args[0], args[1:]...)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return command, fmt.Errorf("failed to schedule %s: %v, output: %s", action, err, string(output))
+		}
+		return command, nil
+	}
+
+	if err := startDetached(args); err != nil {
+		return command, fmt.Errorf("failed to schedule %s: %v", action, err)
 	}
 
 	return command, nil
 }
 
-// Cancel cancels a scheduled shutdown on Windows
-func (e *WindowsExecutor) Cancel(dryRun bool) error {
+// Cancel cancels a scheduled power action on Windows
+func (e *WindowsExecutor) Cancel(action Action, dryRun bool) error {
 	if dryRun {
 		return nil
 	}
 
 	cmd := exec.Command("shutdown.exe", "/a")
+	if !e.usesShutdown(action) {
+		// Stop the delayed launcher started by Schedule, skipping this process
+		script := fmt.Sprintf("Get-CimInstance Win32_Process | "+
+			"Where-Object { $_.CommandLine -like '*%s*' -and $_.ProcessId -ne $PID } | "+
+			"Invoke-CimMethod -MethodName Terminate", delayMarker)
+		cmd = exec.Command("powershell.exe", "-NoProfile", "-Command", script)
+	}
+
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("failed to cancel shutdown: %v, output: %s", err, string(output))
+		return fmt.Errorf("failed to cancel %s: %v, output: %s", action, err, string(output))
 	}
 
 	return nil
 }
 
+// Execute performs a power action immediately
+func (e *WindowsExecutor) Execute(action Action, dryRun bool) (string, error) {
+	args, err := e.actionArgs(action)
+	if err != nil {
+		return "", err
+	}
+	return runNow(action, args, dryRun)
+}
+
 // GetOS returns the OS name
 func (e *WindowsExecutor) GetOS() string {
 	return "windows"
 }
+
+// usesShutdown reports whether action is scheduled through shutdown.exe
+func (e *WindowsExecutor) usesShutdown(action Action) bool {
+	return action == ActionPowerOff || action == ActionReboot
+}
+
+// scheduleArgs returns the command that performs action after the given minutes
+func (e *WindowsExecutor) scheduleArgs(action Action, minutes int) ([]string, error) {
+	seconds := strconv.Itoa(minutes * 60)
+
+	switch action {
+	case ActionPowerOff:
+		return []string{"shutdown.exe", "/s", "/t", seconds}, nil
+	case ActionReboot:
+		return []string{"shutdown.exe", "/r", "/t", seconds}, nil
+	}
+
+	// Other actions have no native delay, so wait in a hidden PowerShell
+	args, err := e.actionArgs(action)
+	if err != nil {
+		return nil, err
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", "''") + "'"
+	}
+	script := fmt.Sprintf("$gts='%s'; Start-Sleep -Seconds %s; & %s", delayMarker, seconds, strings.Join(quoted, " "))
+	return []string{"powershell.exe", "-NoProfile", "-WindowStyle", "Hidden", "-Command", script}, nil
+}
+
+// actionArgs returns the command that performs action immediately
+func (e *WindowsExecutor) actionArgs(action Action) ([]string, error) {
+	switch action {
+	case ActionPowerOff:
+		return []string{"shutdown.exe", "/s", "/t", "0"}, nil
+	case ActionReboot:
+		return []string{"shutdown.exe", "/r", "/t", "0"}, nil
+	case ActionSuspend:
+		return []string{"rundll32.exe", "powrprof.dll,SetSuspendState", "0,1,0"}, nil
+	case ActionHibernate:
+		return []string{"shutdown.exe", "/h"}, nil
+	case ActionLogout:
+		return []string{"shutdown.exe", "/l"}, nil
+	case ActionLock:
+		return []string{"rundll32.exe", "user32.dll,LockWorkStation"}, nil
+	}
+
+	return nil, unsupportedAction(action, e.GetOS())
+}
@@ -0,0 +1,171 @@
+package trigger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+// PowerState is a snapshot of the batteries and external power of the machine
+type PowerState struct {
+	Percent   float64 // charge of all batteries combined
+	OnBattery bool    // no external power is connected
+}
+
+// BatterySource reads the power state of the machine
+type BatterySource interface {
+	// Power fails when the machine has no battery
+	Power() (PowerState, error)
+}
+
+// NewBatterySource returns the battery source of the current OS
+func NewBatterySource() BatterySource {
+	switch runtime.GOOS {
+	case "linux":
+		return &SysfsPower{Root: "/sys/class/power_supply"}
+	default:
+		return unsupportedBattery{}
+	}
+}
+
+// BatteryWatcher is met once the battery has drained below a percentage
+// while the machine runs on it, and stays met until external power returns
+type BatteryWatcher struct {
+	percent float64
+	source  BatterySource
+	low     bool
+}
+
+// NewBatteryWatcher creates a watcher for the percentage of t
+func NewBatteryWatcher(t config.Trigger, source BatterySource) *BatteryWatcher {
+	return &BatteryWatcher{
+		percent: float64(t.BatteryBelow),
+		source:  source,
+	}
+}
+
+// Check reads the power state from the source
+func (w *BatteryWatcher) Check(now time.Time) (Reading, error) {
+	state, err := w.source.Power()
+	if err != nil {
+		return Reading{}, err
+	}
+
+	detail := fmt.Sprintf("battery %.0f%% / %g%%", state.Percent, w.percent)
+	if !state.OnBattery {
+		w.low = false
+		return Reading{Detail: detail + ", on AC"}, nil
+	}
+
+	// Charge readings jitter, only external power ends a low battery
+	if state.Percent < w.percent {
+		w.low = true
+	}
+	return Reading{Met: w.low, Detail: detail + ", discharging"}, nil
+}
+
+// SysfsPower reads power supplies from a directory laid out like
+// /sys/class/power_supply, with one directory per supply
+type SysfsPower struct {
+	Root string
+}
+
+// Power combines the charge of all system batteries, weighted by their
+// capacity when the kernel reports it in the same unit for all of them.
+// Peripheral batteries, such as the one in a wireless mouse, are ignored.
+func (p *SysfsPower) Power() (PowerState, error) {
+	entries, err := os.ReadDir(p.Root)
+	if err != nil {
+		return PowerState{}, fmt.Errorf("failed to list power supplies: %w", err)
+	}
+
+	var state PowerState
+	var charge, full, percentSum float64
+	var unit string
+	batteries, weighted := 0, true
+	discharging, external, online := false, false, false
+	for _, entry := range entries {
+		dir := filepath.Join(p.Root, entry.Name())
+		if p.read(dir, "scope") == "Device" {
+			continue
+		}
+
+		switch p.read(dir, "type") {
+		case "Battery":
+			percent, err := strconv.ParseFloat(p.read(dir, "capacity"), 64)
+			if err != nil {
+				continue
+			}
+			batteries++
+			percentSum += percent
+			// µWh and µAh cannot be added up, the plain average has to do
+			if now, total, u, ok := p.energy(dir); ok && (unit == "" || u == unit) {
+				charge += now
+				full += total
+				unit = u
+			} else {
+				weighted = false
+			}
+			if p.read(dir, "status") == "Discharging" {
+				discharging = true
+			}
+		case "Mains", "USB", "USB_C", "USB_PD":
+			external = true
+			if p.read(dir, "online") == "1" {
+				online = true
+			}
+		}
+	}
+
+	if batteries == 0 {
+		return PowerState{}, fmt.Errorf("no battery found in %s", p.Root)
+	}
+
+	state.Percent = percentSum / float64(batteries)
+	if weighted && full > 0 {
+		state.Percent = 100 * charge / full
+	}
+	// Trust the external supplies when there are any, a full battery on AC
+	// may well report that it is discharging
+	state.OnBattery = discharging
+	if external {
+		state.OnBattery = !online
+	}
+	return state, nil
+}
+
+// energy reads the current and full charge of a battery and the attribute
+// prefix naming their unit, drivers report either energy in µWh or charge in µAh
+func (p *SysfsPower) energy(dir string) (float64, float64, string, bool) {
+	for _, prefix := range []string{"energy", "charge"} {
+		now, errNow := strconv.ParseFloat(p.read(dir, prefix+"_now"), 64)
+		full, errFull := strconv.ParseFloat(p.read(dir, prefix+"_full"), 64)
+		if errNow == nil && errFull == nil && full > 0 {
+			return now, full, prefix, true
+		}
+	}
+	return 0, 0, "", false
+}
+
+// read returns the trimmed contents of an attribute, empty when it is missing
+func (p *SysfsPower) read(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// unsupportedBattery is used on platforms without a battery source
+type unsupportedBattery struct{}
+
+// Power always fails
+func (unsupportedBattery) Power() (PowerState, error) {
+	return PowerState{}, fmt.Errorf("battery triggers need /sys/class/power_supply, which %s does not have", runtime.GOOS)
+}
@@ -0,0 +1,77 @@
+package trigger
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+func TestSysfsPower(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		percent   float64
+		onBattery bool
+	}{
+		{fixture: "ac", percent: 42},
+		{fixture: "one", percent: 42, onBattery: true}, // no AC supply, the status decides
+		{fixture: "two", percent: 100 * 45 / 70.0, onBattery: true},
+		{fixture: "mixed", percent: (80 + 25) / 2.0, onBattery: true},
+		{fixture: "nofull", percent: (37 + 90) / 2.0, onBattery: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			p := &SysfsPower{Root: "testdata/power_supply/" + tt.fixture}
+			state, err := p.Power()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(state.Percent-tt.percent) > 1e-9 || state.OnBattery != tt.onBattery {
+				t.Errorf("Power() = %+v, want %.2f%%, on battery %v", state, tt.percent, tt.onBattery)
+			}
+		})
+	}
+
+	for _, root := range []string{"testdata/power_supply/desktop", "testdata/power_supply/missing"} {
+		if state, err := (&SysfsPower{Root: root}).Power(); err == nil {
+			t.Errorf("%s: Power() = %+v, want an error", root, state)
+		}
+	}
+}
+
+func TestBatteryWatcher(t *testing.T) {
+	power := &SysfsPower{}
+	w := NewBatteryWatcher(config.Trigger{BatteryBelow: 50}, power)
+
+	steps := []struct {
+		fixture string
+		met     bool
+		detail  string
+	}{
+		{fixture: "ac", detail: "battery 42% / 50%, on AC"},
+		{fixture: "two", detail: "battery 64% / 50%, discharging"},
+		{fixture: "one", met: true, detail: "battery 42% / 50%, discharging"},
+		// Readings jitter, a low battery stays low until AC returns
+		{fixture: "mixed", met: true, detail: "battery 52% / 50%, discharging"},
+		{fixture: "ac", detail: "battery 42% / 50%, on AC"},
+		{fixture: "mixed", detail: "battery 52% / 50%, discharging"},
+	}
+
+	for i, step := range steps {
+		power.Root = "testdata/power_supply/" + step.fixture
+		reading, err := w.Check(time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reading.Met != step.met || reading.Detail != step.detail {
+			t.Errorf("step %d (%s): reading = %+v, want met %v, %q", i+1, step.fixture, reading, step.met, step.detail)
+		}
+	}
+
+	power.Root = "testdata/power_supply/desktop"
+	if _, err := w.Check(time.Now()); err == nil {
+		t.Error("Check() without a battery succeeded")
+	}
+}
@@ -0,0 +1,62 @@
+package trigger
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// IdleSource reports how long the user has been inactive
+type IdleSource interface {
+	// IdleTime returns the time since the last user input, 0 while the user is active
+	IdleTime(now time.Time) (time.Duration, error)
+}
+
+// NewIdleSource returns the idle source of the current OS
+func NewIdleSource() IdleSource {
+	switch runtime.GOOS {
+	case "linux":
+		return &LogindIdle{}
+	case "darwin":
+		return &IORegIdle{}
+	case "windows":
+		return &LastInputIdle{}
+	default:
+		return unsupportedIdle{}
+	}
+}
+
+// IdleWatcher is met once the user has been idle for a threshold
+type IdleWatcher struct {
+	threshold time.Duration
+	source    IdleSource
+}
+
+// NewIdleWatcher creates a watcher that is met after threshold of inactivity
+func NewIdleWatcher(threshold time.Duration, source IdleSource) *IdleWatcher {
+	return &IdleWatcher{
+		threshold: threshold,
+		source:    source,
+	}
+}
+
+// Check reads the idle time from the source
+func (w *IdleWatcher) Check(now time.Time) (Reading, error) {
+	idle, err := w.source.IdleTime(now)
+	if err != nil {
+		return Reading{}, err
+	}
+
+	return Reading{
+		Met:    idle >= w.threshold,
+		Detail: fmt.Sprintf("idle %s / %s", formatSpan(idle), formatSpan(w.threshold)),
+	}, nil
+}
+
+// unsupportedIdle is used on platforms without an idle source
+type unsupportedIdle struct{}
+
+// IdleTime always fails
+func (unsupportedIdle) IdleTime(now time.Time) (time.Duration, error) {
+	return 0, fmt.Errorf("idle detection is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,114 @@
+package trigger
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+// fakeIdle returns the idle time and error set by the test
+type fakeIdle struct {
+	idle time.Duration
+	err  error
+}
+
+func (s *fakeIdle) IdleTime(now time.Time) (time.Duration, error) {
+	return s.idle, s.err
+}
+
+func TestIdleWatcher(t *testing.T) {
+	source := &fakeIdle{}
+	w := NewIdleWatcher(10*time.Minute, source)
+
+	steps := []struct {
+		name   string
+		idle   time.Duration
+		err    error
+		met    bool
+		detail string
+	}{
+		{name: "active", detail: "idle 0m / 10m"},
+		{name: "below the threshold", idle: 9*time.Minute + 59*time.Second, detail: "idle 9m / 10m"},
+		{name: "crossing the threshold", idle: 10 * time.Minute, met: true, detail: "idle 10m / 10m"},
+		{name: "past the threshold", idle: 25 * time.Minute, met: true, detail: "idle 25m / 10m"},
+		{name: "activity resets", detail: "idle 0m / 10m"},
+		{name: "source error", err: errors.New("no session bus")},
+		{name: "idle again", idle: 3 * time.Minute, detail: "idle 3m / 10m"},
+	}
+
+	for _, step := range steps {
+		source.idle, source.err = step.idle, step.err
+		reading, err := w.Check(time.Now())
+		if step.err != nil {
+			if !errors.Is(err, step.err) {
+				t.Errorf("%s: err = %v, want %v", step.name, err, step.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if reading.Met != step.met || reading.Detail != step.detail {
+			t.Errorf("%s: reading = %+v, want met %v, %q", step.name, reading, step.met, step.detail)
+		}
+	}
+
+	// Activity during the countdown sends the job back to waiting
+	if !Rearms(config.Trigger{Kind: config.TriggerIdle}) {
+		t.Error("idle jobs do not rearm on activity")
+	}
+}
+
+// fakeLogindIdle answers the idle hints of the logind manager
+type fakeLogindIdle struct {
+	dbus.BusObject
+
+	hint  bool
+	since uint64 // IdleSinceHint in microseconds
+	err   error
+}
+
+func (m *fakeLogindIdle) GetProperty(p string) (dbus.Variant, error) {
+	if m.err != nil {
+		return dbus.Variant{}, m.err
+	}
+	switch p {
+	case logindName + ".Manager.IdleHint":
+		return dbus.MakeVariant(m.hint), nil
+	case logindName + ".Manager.IdleSinceHint":
+		return dbus.MakeVariant(m.since), nil
+	}
+	return dbus.Variant{}, fmt.Errorf("unknown property %s", p)
+}
+
+func TestLogindIdle(t *testing.T) {
+	now := time.Date(2025, 1, 1, 23, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		manager *fakeLogindIdle
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "active", manager: &fakeLogindIdle{since: uint64(now.Add(-time.Hour).UnixMicro())}},
+		{name: "idle", manager: &fakeLogindIdle{hint: true, since: uint64(now.Add(-12 * time.Minute).UnixMicro())}, want: 12 * time.Minute},
+		{name: "idle without a time", manager: &fakeLogindIdle{hint: true}},
+		{name: "clock behind the hint", manager: &fakeLogindIdle{hint: true, since: uint64(now.Add(time.Minute).UnixMicro())}},
+		{name: "bus error", manager: &fakeLogindIdle{err: errors.New("access denied")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := (&LogindIdle{Manager: tt.manager}).IdleTime(now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("IdleTime() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
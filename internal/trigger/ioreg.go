@@ -0,0 +1,33 @@
+package trigger
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// hidIdlePattern matches the idle time ioreg reports for the HID system in nanoseconds
+var hidIdlePattern = regexp.MustCompile(`"HIDIdleTime" = (\d+)`)
+
+// IORegIdle reads the HID idle time on macOS
+type IORegIdle struct{}
+
+// IdleTime returns the time since the last keyboard or mouse input
+func (s *IORegIdle) IdleTime(now time.Time) (time.Duration, error) {
+	output, err := exec.Command("ioreg", "-c", "IOHIDSystem", "-d", "4").Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to run ioreg: %w", err)
+	}
+
+	match := hidIdlePattern.FindSubmatch(output)
+	if match == nil {
+		return 0, fmt.Errorf("failed to find HIDIdleTime in ioreg output")
+	}
+	ns, err := strconv.ParseInt(string(match[1]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse HIDIdleTime: %w", err)
+	}
+	return time.Duration(ns), nil
+}
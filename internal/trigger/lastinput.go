@@ -0,0 +1,12 @@
+package trigger
+
+import "time"
+
+// LastInputIdle reads the time of the last input event of the session on Windows.
+// The daemon must run in the user's session, a service cannot see its input.
+type LastInputIdle struct{}
+
+// IdleTime returns the time since the last keyboard or mouse input
+func (s *LastInputIdle) IdleTime(now time.Time) (time.Duration, error) {
+	return lastInputIdle()
+}
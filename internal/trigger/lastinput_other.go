@@ -0,0 +1,13 @@
+//go:build !windows
+
+package trigger
+
+import (
+	"errors"
+	"time"
+)
+
+// lastInputIdle is only available on Windows
+func lastInputIdle() (time.Duration, error) {
+	return 0, errors.New("last input time is only available on Windows")
+}
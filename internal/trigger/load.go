@@ -0,0 +1,189 @@
+package trigger
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+// cpuSampleInterval is the shortest span CPU usage is measured over
+const cpuSampleInterval = 5 * time.Second
+
+// LoadSource reads how busy the machine is
+type LoadSource interface {
+	// LoadAverage returns the load average over the last minute
+	LoadAverage() (float64, error)
+	// CPUTimes returns the busy and total time spent by all CPUs since boot,
+	// in clock ticks
+	CPUTimes() (busy, total uint64, err error)
+}
+
+// NewLoadSource returns the load source of the current OS
+func NewLoadSource() LoadSource {
+	switch runtime.GOOS {
+	case "linux":
+		return &ProcLoad{Root: "/proc"}
+	default:
+		return unsupportedLoad{}
+	}
+}
+
+// LoadWatcher is met once the load average has stayed below a level for a window
+type LoadWatcher struct {
+	level  float64
+	source LoadSource
+	quiet  quietTracker
+	lastAt time.Time
+}
+
+// NewLoadWatcher creates a watcher for the level and window of t
+func NewLoadWatcher(t config.Trigger, source LoadSource) *LoadWatcher {
+	return &LoadWatcher{
+		level:  t.LoadBelow,
+		source: source,
+		quiet:  quietTracker{window: time.Duration(t.QuietSeconds) * time.Second},
+	}
+}
+
+// Check reads the load average, which is already smoothed over a minute
+func (w *LoadWatcher) Check(now time.Time) (Reading, error) {
+	load, err := w.source.LoadAverage()
+	if err != nil {
+		return Reading{}, err
+	}
+
+	from := w.lastAt
+	if from.IsZero() {
+		from = now
+	}
+	w.quiet.observe(load < w.level, from)
+	w.lastAt = now
+
+	return w.quiet.reading(now, fmt.Sprintf("load %.2f / %g", load, w.level)), nil
+}
+
+// CPUWatcher is met once the aggregate CPU usage has stayed below a
+// percentage for a window
+type CPUWatcher struct {
+	percent float64
+	source  LoadSource
+	quiet   quietTracker
+
+	busy, total uint64
+	lastAt      time.Time
+	measured    float64
+	hasMeasured bool
+}
+
+// NewCPUWatcher creates a watcher for the percentage and window of t
+func NewCPUWatcher(t config.Trigger, source LoadSource) *CPUWatcher {
+	return &CPUWatcher{
+		percent: t.CPUBelow,
+		source:  source,
+		quiet:   quietTracker{window: time.Duration(t.QuietSeconds) * time.Second},
+	}
+}
+
+// Check samples the CPU times and updates how long usage has been low
+func (w *CPUWatcher) Check(now time.Time) (Reading, error) {
+	if w.lastAt.IsZero() || now.Sub(w.lastAt) >= cpuSampleInterval {
+		busy, total, err := w.source.CPUTimes()
+		if err != nil {
+			return Reading{}, err
+		}
+		if !w.lastAt.IsZero() && total > w.total && busy >= w.busy {
+			w.measured = 100 * float64(busy-w.busy) / float64(total-w.total)
+			w.hasMeasured = true
+			w.quiet.observe(w.measured < w.percent, w.lastAt)
+		}
+		w.busy, w.total, w.lastAt = busy, total, now
+	}
+
+	if !w.hasMeasured {
+		return Reading{Detail: "cpu measuring"}, nil
+	}
+	return w.quiet.reading(now, fmt.Sprintf("cpu %.0f%% / %g%%", w.measured, w.percent)), nil
+}
+
+// ProcLoad reads the load average and CPU times from a proc filesystem
+// mounted at Root
+type ProcLoad struct {
+	Root string
+}
+
+// LoadAverage reads the first field of loadavg
+func (p *ProcLoad) LoadAverage() (float64, error) {
+	data, err := os.ReadFile(filepath.Join(p.Root, "loadavg"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read load average: %w", err)
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("failed to parse load average: empty file")
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse load average: %w", err)
+	}
+	return load, nil
+}
+
+// CPUTimes sums the aggregate "cpu" line of stat, idle and iowait count as
+// not busy
+func (p *ProcLoad) CPUTimes() (uint64, uint64, error) {
+	data, err := os.ReadFile(filepath.Join(p.Root, "stat"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read CPU times: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// cpu  user nice system idle iowait irq softirq steal guest guest_nice
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+
+		var busy, total uint64
+		// Guest time is already part of user and nice
+		for i, field := range fields[1:min(len(fields), 9)] {
+			ticks, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to parse CPU times: %w", err)
+			}
+			total += ticks
+			if i != 3 && i != 4 {
+				busy += ticks
+			}
+		}
+		return busy, total, nil
+	}
+	return 0, 0, fmt.Errorf("failed to parse CPU times: no cpu line")
+}
+
+// unsupportedLoad is used on platforms without a load source
+type unsupportedLoad struct{}
+
+// LoadAverage always fails
+func (unsupportedLoad) LoadAverage() (float64, error) {
+	return 0, errLoadUnsupported()
+}
+
+// CPUTimes always fails
+func (unsupportedLoad) CPUTimes() (uint64, uint64, error) {
+	return 0, 0, errLoadUnsupported()
+}
+
+// errLoadUnsupported reports that load triggers need /proc
+func errLoadUnsupported() error {
+	return fmt.Errorf("load triggers need /proc, which %s does not have", runtime.GOOS)
+}
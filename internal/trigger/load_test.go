@@ -0,0 +1,109 @@
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+func TestProcLoad(t *testing.T) {
+	load, err := (&ProcLoad{Root: "testdata/load/busy"}).LoadAverage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if load != 3.2 {
+		t.Errorf("LoadAverage() = %g, want 3.2", load)
+	}
+
+	// Only the aggregate line counts, guest time is already in user
+	busy, total, err := (&ProcLoad{Root: "testdata/cpu/0"}).CPUTimes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if busy != 13350 || total != 64350 {
+		t.Errorf("CPUTimes() = %d, %d, want 13350, 64350", busy, total)
+	}
+
+	for _, root := range []string{"testdata/load/empty", "testdata/load/missing"} {
+		if _, err := (&ProcLoad{Root: root}).LoadAverage(); err == nil {
+			t.Errorf("%s: LoadAverage() succeeded", root)
+		}
+	}
+	for _, root := range []string{"testdata/cpu/nocpu", "testdata/cpu/missing"} {
+		if _, _, err := (&ProcLoad{Root: root}).CPUTimes(); err == nil {
+			t.Errorf("%s: CPUTimes() succeeded", root)
+		}
+	}
+}
+
+func TestLoadWatcher(t *testing.T) {
+	proc := &ProcLoad{}
+	w := NewLoadWatcher(config.Trigger{LoadBelow: 1, QuietSeconds: 600}, proc)
+	start := time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		at     time.Duration
+		load   string
+		met    bool
+		detail string
+	}{
+		{at: 0, load: "busy", detail: "load 3.20 / 1"},
+		{at: time.Minute, load: "quiet", detail: "load 0.40 / 1, quiet 1m / 10m"},
+		{at: 6 * time.Minute, load: "quiet", detail: "load 0.40 / 1, quiet 6m / 10m"},
+		// A spike starts the quiet period over
+		{at: 7 * time.Minute, load: "spike", detail: "load 1.75 / 1"},
+		{at: 8 * time.Minute, load: "quiet", detail: "load 0.40 / 1, quiet 1m / 10m"},
+		{at: 16 * time.Minute, load: "quiet", detail: "load 0.40 / 1, quiet 9m / 10m"},
+		{at: 17 * time.Minute, load: "quiet", met: true, detail: "load 0.40 / 1, quiet 10m / 10m"},
+	}
+
+	for _, step := range steps {
+		proc.Root = "testdata/load/" + step.load
+		reading, err := w.Check(start.Add(step.at))
+		if err != nil {
+			t.Fatalf("at %s: %v", step.at, err)
+		}
+		if reading.Met != step.met || reading.Detail != step.detail {
+			t.Errorf("at %s: reading = %+v, want met %v, %q", step.at, reading, step.met, step.detail)
+		}
+	}
+}
+
+func TestCPUWatcher(t *testing.T) {
+	proc := &ProcLoad{}
+	w := NewCPUWatcher(config.Trigger{CPUBelow: 10, QuietSeconds: 600}, proc)
+	start := time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		at       time.Duration
+		stat     string
+		measured float64
+		met      bool
+		detail   string
+	}{
+		{at: 0, stat: "0", detail: "cpu measuring"},
+		// Too soon for a new sample, the times are not read again
+		{at: 2 * time.Second, stat: "1", detail: "cpu measuring"},
+		// 1500 of 2000 ticks busy, idle and iowait are not
+		{at: 5 * time.Second, stat: "1", measured: 75, detail: "cpu 75% / 10%"},
+		{at: 5*time.Minute + 5*time.Second, stat: "2", measured: 5, detail: "cpu 5% / 10%, quiet 5m / 10m"},
+		{at: 10*time.Minute + 5*time.Second, stat: "3", measured: 3, met: true, detail: "cpu 3% / 10%, quiet 10m / 10m"},
+		// A spike starts the quiet period over
+		{at: 10*time.Minute + 10*time.Second, stat: "4", measured: 50, detail: "cpu 50% / 10%"},
+	}
+
+	for _, step := range steps {
+		proc.Root = "testdata/cpu/" + step.stat
+		reading, err := w.Check(start.Add(step.at))
+		if err != nil {
+			t.Fatalf("at %s: %v", step.at, err)
+		}
+		if w.measured != step.measured {
+			t.Errorf("at %s: measured %g%%, want %g%%", step.at, w.measured, step.measured)
+		}
+		if reading.Met != step.met || reading.Detail != step.detail {
+			t.Errorf("at %s: reading = %+v, want met %v, %q", step.at, reading, step.met, step.detail)
+		}
+	}
+}
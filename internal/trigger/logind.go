@@ -0,0 +1,54 @@
+package trigger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Name and object path of the logind manager on the system bus
+const (
+	logindName = "org.freedesktop.login1"
+	logindPath = "/org/freedesktop/login1"
+)
+
+// LogindIdle reads the idle hint logind aggregates over all sessions. Desktop
+// environments and screen lockers maintain the hint, so a session without
+// either never becomes idle.
+type LogindIdle struct {
+	Manager dbus.BusObject // nil uses the manager on the shared system bus
+}
+
+// IdleTime returns the time since IdleSinceHint while IdleHint is set
+func (s *LogindIdle) IdleTime(now time.Time) (time.Duration, error) {
+	obj := s.Manager
+	if obj == nil {
+		// The shared connection reconnects by itself once it was lost
+		conn, err := dbus.SystemBus()
+		if err != nil {
+			return 0, fmt.Errorf("failed to connect to system bus: %w", err)
+		}
+		obj = conn.Object(logindName, logindPath)
+	}
+
+	hint, err := obj.GetProperty(logindName + ".Manager.IdleHint")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read idle hint: %w", err)
+	}
+	if idle, _ := hint.Value().(bool); !idle {
+		return 0, nil
+	}
+
+	since, err := obj.GetProperty(logindName + ".Manager.IdleSinceHint")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read idle since hint: %w", err)
+	}
+	usec, _ := since.Value().(uint64)
+	if usec == 0 {
+		return 0, nil
+	}
+
+	// IdleSinceHint is in microseconds of wall-clock time
+	return max(now.Sub(time.UnixMicro(int64(usec))), 0), nil
+}
@@ -0,0 +1,163 @@
+package trigger
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+// Playback states reported by media players
+const (
+	PlaybackPlaying = "Playing"
+	PlaybackPaused  = "Paused"
+	PlaybackStopped = "Stopped"
+)
+
+// Player is a snapshot of one media player
+type Player struct {
+	Name   string // e.g. "vlc" or "firefox.instance_1_42"
+	Status string // one of the Playback* constants
+	Track  string // identifies the current track, empty without one
+	Title  string
+}
+
+// MediaSource lists the media players of the user session
+type MediaSource interface {
+	Players() ([]Player, error)
+}
+
+// NewMediaSource returns the media source of the current OS
+func NewMediaSource() MediaSource {
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return &MPRISSource{}
+	default:
+		return unsupportedMedia{}
+	}
+}
+
+// MediaWatcher is met once playback has stopped, or once the track that
+// was playing when the job was armed has ended
+type MediaWatcher struct {
+	mode   string
+	player string
+	track  string
+	source MediaSource
+}
+
+// NewMediaWatcher creates a watcher for the mode and player of t
+func NewMediaWatcher(t config.Trigger, source MediaSource) *MediaWatcher {
+	return &MediaWatcher{
+		mode:   t.Media,
+		player: t.Player,
+		track:  t.Track,
+		source: source,
+	}
+}
+
+// Check reads the players from the source
+func (w *MediaWatcher) Check(now time.Time) (Reading, error) {
+	players, err := w.source.Players()
+	if err != nil {
+		return Reading{}, err
+	}
+	players = matchPlayers(players, w.player)
+
+	if w.mode == config.MediaTrack {
+		return w.checkTrack(players), nil
+	}
+
+	for _, p := range players {
+		if p.Status == PlaybackPlaying {
+			return Reading{Detail: describePlayer(p)}, nil
+		}
+	}
+	if len(players) > 0 {
+		return Reading{Met: true, Detail: describePlayer(players[0])}, nil
+	}
+	return Reading{Met: true, Detail: "no player"}, nil
+}
+
+// checkTrack is met once the player moved on from the armed track, stopped
+// or went away. A paused track has not ended yet.
+func (w *MediaWatcher) checkTrack(players []Player) Reading {
+	for _, p := range players {
+		if p.Track != w.track {
+			continue
+		}
+		if p.Status == PlaybackStopped {
+			return Reading{Met: true, Detail: describePlayer(p)}
+		}
+		return Reading{Detail: describePlayer(p)}
+	}
+	return Reading{Met: true, Detail: "track ended"}
+}
+
+// resolveMedia checks that a player is playing and records the track a
+// "track" job waits for, along with the player that plays it
+func resolveMedia(t config.Trigger, source MediaSource) (config.Trigger, error) {
+	if t.Media == "" {
+		t.Media = config.MediaStop
+	}
+	if t.Media != config.MediaStop && t.Media != config.MediaTrack {
+		return t, fmt.Errorf("unknown media mode: %s", t.Media)
+	}
+
+	players, err := source.Players()
+	if err != nil {
+		return t, err
+	}
+	for _, p := range matchPlayers(players, t.Player) {
+		if p.Status != PlaybackPlaying {
+			continue
+		}
+		if t.Media == config.MediaTrack {
+			if p.Track == "" {
+				return t, fmt.Errorf("%s does not report its current track", p.Name)
+			}
+			t.Player, t.Track = p.Name, p.Track
+		}
+		return t, nil
+	}
+
+	if t.Player != "" {
+		return t, fmt.Errorf("%s is not playing", t.Player)
+	}
+	return t, fmt.Errorf("no media player is playing")
+}
+
+// matchPlayers returns the players called name, players with several
+// instances append ".instance..." to their name. An empty name matches all.
+func matchPlayers(players []Player, name string) []Player {
+	if name == "" {
+		return players
+	}
+
+	var matched []Player
+	for _, p := range players {
+		if p.Name == name || strings.HasPrefix(p.Name, name+".") {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// describePlayer returns e.g. "vlc playing Big Buck Bunny"
+func describePlayer(p Player) string {
+	detail := p.Name + " " + strings.ToLower(p.Status)
+	if p.Title != "" {
+		detail += " " + p.Title
+	}
+	return detail
+}
+
+// unsupportedMedia is used on platforms without a session bus
+type unsupportedMedia struct{}
+
+// Players always fails
+func (unsupportedMedia) Players() ([]Player, error) {
+	return nil, fmt.Errorf("media triggers need MPRIS, which %s does not have", runtime.GOOS)
+}
@@ -0,0 +1,195 @@
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+// fakeSession serves the bus names and players of a testdata/mpris fixture
+type fakeSession struct {
+	Names   []string `json:"names"`
+	Players map[string]struct {
+		Status   string            `json:"status"`
+		Metadata map[string]string `json:"metadata"`
+	} `json:"players"`
+}
+
+// loadSession reads the fixture testdata/mpris/<name>.json
+func loadSession(t *testing.T, name string) *fakeSession {
+	t.Helper()
+	data, err := os.ReadFile("testdata/mpris/" + name + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s fakeSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+func (s *fakeSession) BusObject() dbus.BusObject {
+	return &fakeBusObject{session: s}
+}
+
+func (s *fakeSession) Object(dest string, path dbus.ObjectPath) dbus.BusObject {
+	return &fakeBusObject{session: s, name: dest, path: path}
+}
+
+// fakeBusObject is the bus daemon when name is empty, otherwise a player
+type fakeBusObject struct {
+	dbus.BusObject
+
+	session *fakeSession
+	name    string
+	path    dbus.ObjectPath
+}
+
+func (o *fakeBusObject) Call(method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	if o.name != "" || method != "org.freedesktop.DBus.ListNames" {
+		return &dbus.Call{Err: fmt.Errorf("unexpected call %s", method)}
+	}
+	return &dbus.Call{Body: []interface{}{o.session.Names}}
+}
+
+func (o *fakeBusObject) GetProperty(p string) (dbus.Variant, error) {
+	// Listed players that are missing have quit since
+	player, ok := o.session.Players[strings.TrimPrefix(o.name, mprisPrefix)]
+	if !ok || o.path != mprisPath {
+		return dbus.Variant{}, fmt.Errorf("no object %s at %s", o.path, o.name)
+	}
+
+	switch p {
+	case mprisInterface + ".PlaybackStatus":
+		return dbus.MakeVariant(player.Status), nil
+	case mprisInterface + ".Metadata":
+		metadata := map[string]dbus.Variant{}
+		for k, v := range player.Metadata {
+			metadata[k] = dbus.MakeVariant(v)
+		}
+		return dbus.MakeVariant(metadata), nil
+	}
+	return dbus.Variant{}, fmt.Errorf("unknown property %s", p)
+}
+
+func TestMPRISSourcePlayers(t *testing.T) {
+	source := &MPRISSource{Bus: loadSession(t, "playing")}
+	players, err := source.Players()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sorted by bus name, spotify quit while it was read
+	want := []Player{
+		{
+			Name:   "firefox.instance_1_42",
+			Status: PlaybackPaused,
+			Track:  "https://example.com/podcast.mp3",
+		},
+		{
+			Name:   "vlc",
+			Status: PlaybackPlaying,
+			Track:  "/org/videolan/vlc/playlist/3|file:///home/user/Videos/big_buck_bunny.mkv|Big Buck Bunny",
+			Title:  "Big Buck Bunny",
+		},
+	}
+	if !reflect.DeepEqual(players, want) {
+		t.Errorf("players = %+v, want %+v", players, want)
+	}
+}
+
+func TestMediaWatcherStop(t *testing.T) {
+	source := &MPRISSource{}
+	steps := []struct {
+		fixture string
+		met     bool
+		detail  string
+	}{
+		{fixture: "playing", detail: "vlc playing Big Buck Bunny"},
+		{fixture: "next", detail: "vlc playing Sintel"},
+		{fixture: "paused", met: true, detail: "vlc paused Big Buck Bunny"},
+		{fixture: "stopped", met: true, detail: "vlc stopped Big Buck Bunny"},
+		{fixture: "none", met: true, detail: "no player"},
+	}
+
+	source.Bus = loadSession(t, "playing")
+	trigger, err := resolveMedia(config.Trigger{}, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewMediaWatcher(trigger, source)
+
+	for _, step := range steps {
+		source.Bus = loadSession(t, step.fixture)
+		reading, err := w.Check(time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reading.Met != step.met || reading.Detail != step.detail {
+			t.Errorf("%s: reading = %+v, want met %v, %q", step.fixture, reading, step.met, step.detail)
+		}
+	}
+}
+
+func TestMediaWatcherTrack(t *testing.T) {
+	tests := []struct {
+		fixture string
+		met     bool
+		detail  string
+	}{
+		{fixture: "playing", detail: "vlc playing Big Buck Bunny"},
+		{fixture: "paused", detail: "vlc paused Big Buck Bunny"},
+		{fixture: "next", met: true, detail: "track ended"},
+		{fixture: "stopped", met: true, detail: "vlc stopped Big Buck Bunny"},
+		{fixture: "none", met: true, detail: "track ended"},
+	}
+
+	source := &MPRISSource{Bus: loadSession(t, "playing")}
+	trigger, err := resolveMedia(config.Trigger{Media: config.MediaTrack}, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trigger.Player != "vlc" || !strings.HasPrefix(trigger.Track, "/org/videolan/vlc/playlist/3|") {
+		t.Fatalf("armed %q on %q", trigger.Track, trigger.Player)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			source.Bus = loadSession(t, tt.fixture)
+			reading, err := NewMediaWatcher(trigger, source).Check(time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reading.Met != tt.met || reading.Detail != tt.detail {
+				t.Errorf("reading = %+v, want met %v, %q", reading, tt.met, tt.detail)
+			}
+		})
+	}
+}
+
+func TestResolveMediaErrors(t *testing.T) {
+	tests := []struct {
+		fixture string
+		trigger config.Trigger
+	}{
+		{fixture: "paused", trigger: config.Trigger{}},
+		{fixture: "none", trigger: config.Trigger{}},
+		{fixture: "playing", trigger: config.Trigger{Player: "firefox"}},
+		{fixture: "playing", trigger: config.Trigger{Media: "album"}},
+	}
+
+	for _, tt := range tests {
+		source := &MPRISSource{Bus: loadSession(t, tt.fixture)}
+		if _, err := resolveMedia(tt.trigger, source); err == nil {
+			t.Errorf("%s %+v: resolved", tt.fixture, tt.trigger)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package trigger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Bus name prefix, object path and interface shared by all MPRIS players
+const (
+	mprisPrefix    = "org.mpris.MediaPlayer2."
+	mprisPath      = "/org/mpris/MediaPlayer2"
+	mprisInterface = "org.mpris.MediaPlayer2.Player"
+)
+
+// SessionBus is the part of a D-Bus connection MPRISSource reads players
+// from, satisfied by *dbus.Conn
+type SessionBus interface {
+	BusObject() dbus.BusObject
+	Object(dest string, path dbus.ObjectPath) dbus.BusObject
+}
+
+// MPRISSource lists the players that implement MPRIS on the session bus
+type MPRISSource struct {
+	Bus SessionBus // nil uses the shared session bus connection
+}
+
+// Players reads the playback status and current track of every player
+func (s *MPRISSource) Players() ([]Player, error) {
+	conn := s.Bus
+	if conn == nil {
+		// The shared connection reconnects by itself once it was lost
+		session, err := dbus.SessionBus()
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to session bus: %w", err)
+		}
+		conn = session
+	}
+
+	var names []string
+	if err := conn.BusObject().Call("org.freedesktop.DBus.ListNames", 0).Store(&names); err != nil {
+		return nil, fmt.Errorf("failed to list bus names: %w", err)
+	}
+	sort.Strings(names)
+
+	var players []Player
+	for _, name := range names {
+		if !strings.HasPrefix(name, mprisPrefix) {
+			continue
+		}
+
+		// Players may quit while we read them, so errors just skip them
+		obj := conn.Object(name, mprisPath)
+		status, err := obj.GetProperty(mprisInterface + ".PlaybackStatus")
+		if err != nil {
+			continue
+		}
+		p := Player{Name: strings.TrimPrefix(name, mprisPrefix)}
+		p.Status, _ = status.Value().(string)
+
+		if metadata, err := obj.GetProperty(mprisInterface + ".Metadata"); err == nil {
+			values, _ := metadata.Value().(map[string]dbus.Variant)
+			p.Track, p.Title = trackOf(values)
+		}
+		players = append(players, p)
+	}
+	return players, nil
+}
+
+// trackOf identifies the track described by MPRIS metadata. Players differ
+// in which fields they fill, so all of the identifying ones are combined.
+func trackOf(metadata map[string]dbus.Variant) (track, title string) {
+	var parts []string
+	for _, key := range []string{"mpris:trackid", "xesam:url", "xesam:title"} {
+		if v, ok := metadata[key]; ok {
+			parts = append(parts, fmt.Sprint(v.Value()))
+		}
+	}
+	if v, ok := metadata["xesam:title"]; ok {
+		title, _ = v.Value().(string)
+	}
+	return strings.Join(parts, "|"), title
+}
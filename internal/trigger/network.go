@@ -0,0 +1,154 @@
+package trigger
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// networkSampleInterval is the shortest span a traffic rate is measured
+// over, single seconds are too noisy for bursty downloads
+const networkSampleInterval = 5 * time.Second
+
+// NetworkSource reads the traffic counters of network interfaces
+type NetworkSource interface {
+	// Bytes returns the bytes received plus sent by iface since it came up,
+	// an empty iface sums every interface but loopback
+	Bytes(iface string) (uint64, error)
+}
+
+// NewNetworkSource returns the network source of the current OS
+func NewNetworkSource() NetworkSource {
+	switch runtime.GOOS {
+	case "linux":
+		return &ProcNetDev{Path: "/proc/net/dev"}
+	default:
+		return unsupportedNetwork{}
+	}
+}
+
+// NetworkWatcher is met once the traffic of an interface has stayed below a
+// rate for a window
+type NetworkWatcher struct {
+	iface  string
+	rate   float64
+	source NetworkSource
+	quiet  quietTracker
+
+	last        uint64
+	lastAt      time.Time
+	measured    float64
+	hasMeasured bool
+}
+
+// NewNetworkWatcher creates a watcher for the interface and thresholds of t
+func NewNetworkWatcher(t config.Trigger, source NetworkSource) *NetworkWatcher {
+	return &NetworkWatcher{
+		iface:  t.Interface,
+		rate:   float64(t.RateBytes),
+		source: source,
+		quiet:  quietTracker{window: time.Duration(t.QuietSeconds) * time.Second},
+	}
+}
+
+// Check samples the counters and updates how long traffic has been quiet
+func (w *NetworkWatcher) Check(now time.Time) (Reading, error) {
+	if w.lastAt.IsZero() || now.Sub(w.lastAt) >= networkSampleInterval {
+		if err := w.sample(now); err != nil {
+			return Reading{}, err
+		}
+	}
+
+	name := w.iface
+	if name == "" {
+		name = "network"
+	}
+	if !w.hasMeasured {
+		return Reading{Detail: name + " measuring"}, nil
+	}
+	return w.quiet.reading(now, fmt.Sprintf("%s %s / %s", name, utils.FormatRate(w.measured), utils.FormatRate(w.rate))), nil
+}
+
+// sample reads the counters and measures the rate since the previous sample
+func (w *NetworkWatcher) sample(now time.Time) error {
+	total, err := w.source.Bytes(w.iface)
+	if err != nil {
+		return err
+	}
+
+	// A counter that went backwards belongs to an interface that was reset,
+	// it only serves as the new baseline
+	if !w.lastAt.IsZero() && total >= w.last {
+		w.measured = float64(total-w.last) / now.Sub(w.lastAt).Seconds()
+		w.hasMeasured = true
+		w.quiet.observe(w.measured < w.rate, w.lastAt)
+	}
+
+	w.last, w.lastAt = total, now
+	return nil
+}
+
+// ProcNetDev reads traffic counters from a file in the /proc/net/dev format
+type ProcNetDev struct {
+	Path string
+}
+
+// Bytes sums the receive and transmit byte counters of iface
+func (p *ProcNetDev) Bytes(iface string) (uint64, error) {
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read network counters: %w", err)
+	}
+
+	var total uint64
+	found := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// Lines look like "  eth0: 1234 12 0 0 0 0 0 0 5678 34 0 ...", the
+		// two header lines have no colon
+		name, counters, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if iface == "" && name == "lo" || iface != "" && name != iface {
+			continue
+		}
+
+		fields := strings.Fields(counters)
+		if len(fields) < 9 {
+			return 0, fmt.Errorf("malformed counters for %s", name)
+		}
+		rx, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid counters for %s: %w", name, err)
+		}
+		tx, err := strconv.ParseUint(fields[8], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid counters for %s: %w", name, err)
+		}
+		total += rx + tx
+		found = true
+	}
+
+	if !found && iface != "" {
+		return 0, fmt.Errorf("network interface %s not found", iface)
+	}
+	return total, nil
+}
+
+// unsupportedNetwork is used on platforms without a network source
+type unsupportedNetwork struct{}
+
+// Bytes always fails
+func (unsupportedNetwork) Bytes(iface string) (uint64, error) {
+	return 0, fmt.Errorf("network triggers need /proc/net/dev, which %s does not have", runtime.GOOS)
+}
@@ -0,0 +1,75 @@
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+func TestProcNetDevBytes(t *testing.T) {
+	tests := []struct {
+		file    string
+		iface   string
+		want    uint64
+		wantErr bool
+	}{
+		{file: "dev.0", iface: "eth0", want: 1200000},
+		{file: "dev.0", iface: "", want: 1210000}, // loopback left out
+		{file: "dev.0", iface: "lo", want: 1999998},
+		{file: "dev.0", iface: "ppp0", wantErr: true},
+		{file: "dev.malformed", iface: "eth0", wantErr: true},
+		{file: "missing", iface: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		p := &ProcNetDev{Path: "testdata/net/" + tt.file}
+		got, err := p.Bytes(tt.iface)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s %q: err = %v, want error %v", tt.file, tt.iface, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s %q = %d, want %d", tt.file, tt.iface, got, tt.want)
+		}
+	}
+}
+
+func TestNetworkWatcherRate(t *testing.T) {
+	dev := &ProcNetDev{}
+	w := NewNetworkWatcher(config.Trigger{Interface: "eth0", RateBytes: 10000, QuietSeconds: 600}, dev)
+	start := time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		at       time.Duration
+		file     string
+		measured float64
+		met      bool
+		detail   string
+	}{
+		{at: 0, file: "dev.0", detail: "eth0 measuring"},
+		// Too soon for a new sample, the counters are not read again
+		{at: 2 * time.Second, file: "dev.1", detail: "eth0 measuring"},
+		{at: 5 * time.Second, file: "dev.1", measured: 1100000, detail: "eth0 1 MB/s / 9.8 KB/s"},
+		// The rate is measured over the whole span since the last sample
+		{at: 5*time.Minute + 5*time.Second, file: "dev.2", measured: 1000.0 / 300, detail: "eth0 3 B/s / 9.8 KB/s, quiet 5m / 10m"},
+		{at: 10*time.Minute + 5*time.Second, file: "dev.2", met: true, detail: "eth0 0 B/s / 9.8 KB/s, quiet 10m / 10m"},
+		// The interface was reset, the counters only serve as a new baseline
+		{at: 15*time.Minute + 5*time.Second, file: "dev.reset", met: true, detail: "eth0 0 B/s / 9.8 KB/s, quiet 15m / 10m"},
+		{at: 15*time.Minute + 10*time.Second, file: "dev.1", measured: (6700000 - 6000) / 5, detail: "eth0 1.3 MB/s / 9.8 KB/s"},
+	}
+
+	for _, step := range steps {
+		dev.Path = "testdata/net/" + step.file
+		reading, err := w.Check(start.Add(step.at))
+		if err != nil {
+			t.Fatalf("at %s: %v", step.at, err)
+		}
+		if w.measured != step.measured {
+			t.Errorf("at %s: measured %.0f B/s, want %.0f", step.at, w.measured, step.measured)
+		}
+		if reading.Met != step.met || reading.Detail != step.detail {
+			t.Errorf("at %s: reading = %+v, want met %v, %q", step.at, reading, step.met, step.detail)
+		}
+	}
+}
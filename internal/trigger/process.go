@@ -0,0 +1,130 @@
+package trigger
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+// ProcessSource looks up running processes
+type ProcessSource interface {
+	// Lookup returns the command name and start time of pid, ok is false once
+	// the process has exited
+	Lookup(pid int) (name string, start uint64, ok bool, err error)
+	// Find returns the PIDs of the running processes named name
+	Find(name string) ([]int, error)
+}
+
+// NewProcessSource returns the process source of the current OS
+func NewProcessSource() ProcessSource {
+	switch runtime.GOOS {
+	case "linux":
+		return &ProcFS{Root: "/proc"}
+	default:
+		return unsupportedProcess{}
+	}
+}
+
+// ProcessWatcher is met once the watched process has exited, either a
+// single PID or every process with a given name
+type ProcessWatcher struct {
+	pid    int
+	start  uint64
+	name   string
+	source ProcessSource
+}
+
+// NewProcessWatcher creates a watcher for the process of t
+func NewProcessWatcher(t config.Trigger, source ProcessSource) *ProcessWatcher {
+	return &ProcessWatcher{
+		pid:    t.PID,
+		start:  t.ProcessStart,
+		name:   t.Process,
+		source: source,
+	}
+}
+
+// Check looks up the watched process in the source
+func (w *ProcessWatcher) Check(now time.Time) (Reading, error) {
+	if w.pid > 0 {
+		_, start, ok, err := w.source.Lookup(w.pid)
+		if err != nil {
+			return Reading{}, err
+		}
+		// A different start time means the PID was reused by another process
+		if !ok || (w.start != 0 && start != w.start) {
+			return Reading{Met: true, Detail: fmt.Sprintf("%s exited", describeProcess(w.pid, w.name))}, nil
+		}
+		return Reading{Detail: fmt.Sprintf("%s running", describeProcess(w.pid, w.name))}, nil
+	}
+
+	pids, err := w.source.Find(w.name)
+	if err != nil {
+		return Reading{}, err
+	}
+	switch len(pids) {
+	case 0:
+		return Reading{Met: true, Detail: fmt.Sprintf("%s exited", w.name)}, nil
+	case 1:
+		return Reading{Detail: fmt.Sprintf("%s running", describeProcess(pids[0], w.name))}, nil
+	default:
+		return Reading{Detail: fmt.Sprintf("%d %s processes running", len(pids), w.name)}, nil
+	}
+}
+
+// resolveProcess checks that the process of t is running and records the
+// name and start time of a watched PID
+func resolveProcess(t config.Trigger, source ProcessSource) (config.Trigger, error) {
+	if t.PID > 0 {
+		name, start, ok, err := source.Lookup(t.PID)
+		if err != nil {
+			return t, err
+		}
+		if !ok {
+			return t, fmt.Errorf("no process with pid %d is running", t.PID)
+		}
+		t.Process, t.ProcessStart = name, start
+		return t, nil
+	}
+
+	if t.Process == "" {
+		return t, fmt.Errorf("process trigger needs a pid or a name")
+	}
+	pids, err := source.Find(t.Process)
+	if err != nil {
+		return t, err
+	}
+	if len(pids) == 0 {
+		return t, fmt.Errorf("no process named %s is running", t.Process)
+	}
+	return t, nil
+}
+
+// describeProcess returns e.g. "pid 1234 (rsync)"
+func describeProcess(pid int, name string) string {
+	if name == "" {
+		return "pid " + strconv.Itoa(pid)
+	}
+	return fmt.Sprintf("pid %d (%s)", pid, name)
+}
+
+// unsupportedProcess is used on platforms without a process source
+type unsupportedProcess struct{}
+
+// Lookup always fails
+func (unsupportedProcess) Lookup(pid int) (string, uint64, bool, error) {
+	return "", 0, false, errProcessUnsupported()
+}
+
+// Find always fails
+func (unsupportedProcess) Find(name string) ([]int, error) {
+	return nil, errProcessUnsupported()
+}
+
+// errProcessUnsupported reports that process triggers need /proc
+func errProcessUnsupported() error {
+	return fmt.Errorf("process triggers need /proc, which %s does not have", runtime.GOOS)
+}
@@ -0,0 +1,118 @@
+package trigger
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+)
+
+func TestProcFSLookup(t *testing.T) {
+	proc := &ProcFS{Root: "testdata/proc"}
+	tests := []struct {
+		pid   int
+		name  string
+		start uint64
+		ok    bool
+	}{
+		{pid: 1234, name: "rsync", start: 98765, ok: true},
+		{pid: 4567, name: "tmux: (server)", start: 56789, ok: true},
+		{pid: 3456, name: "rsync", start: 99001}, // zombie
+		{pid: 9999},                              // exited
+	}
+
+	for _, tt := range tests {
+		name, start, ok, err := proc.Lookup(tt.pid)
+		if err != nil {
+			t.Fatalf("Lookup(%d): %v", tt.pid, err)
+		}
+		if name != tt.name || start != tt.start || ok != tt.ok {
+			t.Errorf("Lookup(%d) = %q, %d, %v, want %q, %d, %v", tt.pid, name, start, ok, tt.name, tt.start, tt.ok)
+		}
+	}
+}
+
+func TestProcFSFind(t *testing.T) {
+	proc := &ProcFS{Root: "testdata/proc"}
+	tests := []struct {
+		name string
+		want []int
+	}{
+		{name: "rsync", want: []int{1234}}, // the zombie is left out
+		{name: "backup-database-nightly", want: []int{2345}},
+		{name: "backup-database", want: []int{2345}},
+		{name: "make"},
+	}
+
+	for _, tt := range tests {
+		pids, err := proc.Find(tt.name)
+		if err != nil {
+			t.Fatalf("Find(%q): %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(pids, tt.want) {
+			t.Errorf("Find(%q) = %v, want %v", tt.name, pids, tt.want)
+		}
+	}
+}
+
+func TestProcessWatcherPIDReused(t *testing.T) {
+	proc := &ProcFS{Root: "testdata/proc"}
+	trigger, err := resolveProcess(config.Trigger{PID: 1234}, proc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trigger.Process != "rsync" || trigger.ProcessStart != 98765 {
+		t.Fatalf("resolved %q started at %d", trigger.Process, trigger.ProcessStart)
+	}
+
+	w := NewProcessWatcher(trigger, proc)
+	reading, err := w.Check(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reading.Met || reading.Detail != "pid 1234 (rsync) running" {
+		t.Errorf("reading = %+v, want rsync running", reading)
+	}
+
+	// rsync exited and the PID now belongs to a new shell
+	proc.Root = "testdata/proc-reused"
+	reading, err = w.Check(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reading.Met || reading.Detail != "pid 1234 (rsync) exited" {
+		t.Errorf("reading = %+v, want rsync exited", reading)
+	}
+}
+
+func TestProcessWatcherName(t *testing.T) {
+	proc := &ProcFS{Root: "testdata/proc"}
+	w := NewProcessWatcher(config.Trigger{Process: "rsync"}, proc)
+
+	reading, err := w.Check(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reading.Met {
+		t.Errorf("reading = %+v, want rsync running", reading)
+	}
+
+	proc.Root = "testdata/proc-reused"
+	reading, err = w.Check(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reading.Met {
+		t.Errorf("reading = %+v, want rsync exited", reading)
+	}
+}
+
+func TestResolveProcessErrors(t *testing.T) {
+	proc := &ProcFS{Root: "testdata/proc"}
+	for _, trigger := range []config.Trigger{{PID: 3456}, {PID: 9999}, {Process: "make"}, {}} {
+		if _, err := resolveProcess(trigger, proc); err == nil {
+			t.Errorf("resolveProcess(%+v) succeeded", trigger)
+		}
+	}
+}
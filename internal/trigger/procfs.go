@@ -0,0 +1,99 @@
+package trigger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ProcFS reads processes from a proc filesystem mounted at Root
+type ProcFS struct {
+	Root string
+}
+
+// Lookup reads the name and start time of pid from its stat file. Zombies
+// count as exited, they only wait for their parent to reap them.
+func (p *ProcFS) Lookup(pid int) (string, uint64, bool, error) {
+	data, err := os.ReadFile(filepath.Join(p.Root, strconv.Itoa(pid), "stat"))
+	if os.IsNotExist(err) {
+		return "", 0, false, nil
+	}
+	if err != nil {
+		return "", 0, false, fmt.Errorf("failed to read process %d: %w", pid, err)
+	}
+
+	name, state, start, err := parseStat(data)
+	if err != nil {
+		return "", 0, false, fmt.Errorf("failed to parse process %d: %w", pid, err)
+	}
+	if state == "Z" || state == "X" {
+		return name, start, false, nil
+	}
+	return name, start, true, nil
+}
+
+// Find scans the proc filesystem for processes whose command name or
+// executable is name, gts itself is never reported
+func (p *ProcFS) Find(name string) ([]int, error) {
+	entries, err := os.ReadDir(p.Root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+
+		// Processes may exit while we scan, so errors just skip them
+		comm, _, ok, err := p.Lookup(pid)
+		if err != nil || !ok {
+			continue
+		}
+		if comm == name || p.executable(pid) == name {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// executable returns the base name of argv[0] of pid, the command name in
+// stat is cut to 15 characters
+func (p *ProcFS) executable(pid int) string {
+	data, err := os.ReadFile(filepath.Join(p.Root, strconv.Itoa(pid), "cmdline"))
+	if err != nil || len(data) == 0 {
+		return ""
+	}
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		data = data[:i]
+	}
+	return filepath.Base(string(data))
+}
+
+// parseStat extracts the command name, state and start time from the
+// contents of /proc/<pid>/stat
+func parseStat(data []byte) (name, state string, start uint64, err error) {
+	// The name is in parentheses and may itself contain spaces and parentheses
+	open := bytes.IndexByte(data, '(')
+	end := bytes.LastIndexByte(data, ')')
+	if open < 0 || end < open {
+		return "", "", 0, fmt.Errorf("malformed stat line")
+	}
+	name = string(data[open+1 : end])
+
+	// Fields after the name start at field 3 (state), starttime is field 22
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 20 {
+		return "", "", 0, fmt.Errorf("stat line has %d fields", len(fields)+2)
+	}
+	start, err = strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid start time: %w", err)
+	}
+	return name, fields[0], start, nil
+}
@@ -0,0 +1,38 @@
+package trigger
+
+import (
+	"fmt"
+	"time"
+)
+
+// quietTracker measures how long a sampled level has stayed below its
+// threshold, shared by the triggers that wait for the machine to go quiet
+type quietTracker struct {
+	window time.Duration
+	since  time.Time
+}
+
+// observe records a sample taken over the span starting at from, quiet
+// reports whether the level stayed below the threshold
+func (q *quietTracker) observe(quiet bool, from time.Time) {
+	switch {
+	case !quiet:
+		q.since = time.Time{}
+	case q.since.IsZero():
+		q.since = from
+	}
+}
+
+// reading reports whether the level has been quiet for the window, the
+// quiet span is appended to detail while the level is low
+func (q *quietTracker) reading(now time.Time, detail string) Reading {
+	if q.since.IsZero() {
+		return Reading{Detail: detail}
+	}
+
+	quiet := now.Sub(q.since)
+	return Reading{
+		Met:    quiet >= q.window,
+		Detail: fmt.Sprintf("%s, quiet %s / %s", detail, formatSpan(quiet), formatSpan(q.window)),
+	}
+}
@@ -0,0 +1,155 @@
+package trigger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// Reading is one sample of a trigger condition
+type Reading struct {
+	Met    bool
+	Detail string // progress towards the condition, e.g. "idle 3m / 10m"
+}
+
+// Watcher samples the condition of a trigger
+type Watcher interface {
+	// Check samples the condition at now
+	Check(now time.Time) (Reading, error)
+}
+
+// New creates a watcher for t backed by the sources of the current OS
+func New(t config.Trigger) (Watcher, error) {
+	switch t.Kind {
+	case config.TriggerIdle:
+		if t.IdleSeconds <= 0 {
+			return nil, fmt.Errorf("idle trigger needs a positive idle time")
+		}
+		return NewIdleWatcher(time.Duration(t.IdleSeconds)*time.Second, NewIdleSource()), nil
+	case config.TriggerProcess:
+		if t.PID <= 0 && t.Process == "" {
+			return nil, fmt.Errorf("process trigger needs a pid or a name")
+		}
+		return NewProcessWatcher(t, NewProcessSource()), nil
+	case config.TriggerNetwork:
+		if t.RateBytes <= 0 || t.QuietSeconds <= 0 {
+			return nil, fmt.Errorf("network trigger needs a positive rate and quiet time")
+		}
+		return NewNetworkWatcher(t, NewNetworkSource()), nil
+	case config.TriggerLoad:
+		if t.LoadBelow <= 0 || t.QuietSeconds <= 0 {
+			return nil, fmt.Errorf("load trigger needs a positive load and quiet time")
+		}
+		return NewLoadWatcher(t, NewLoadSource()), nil
+	case config.TriggerCPU:
+		if t.CPUBelow <= 0 || t.CPUBelow > 100 || t.QuietSeconds <= 0 {
+			return nil, fmt.Errorf("cpu trigger needs a percentage between 0 and 100 and a positive quiet time")
+		}
+		return NewCPUWatcher(t, NewLoadSource()), nil
+	case config.TriggerMedia:
+		if t.Media == config.MediaTrack && t.Track == "" {
+			return nil, fmt.Errorf("media trigger needs the track to wait for")
+		}
+		return NewMediaWatcher(t, NewMediaSource()), nil
+	case config.TriggerBattery:
+		if t.BatteryBelow <= 0 || t.BatteryBelow > 100 {
+			return nil, fmt.Errorf("battery trigger needs a percentage between 0 and 100")
+		}
+		return NewBatteryWatcher(t, NewBatterySource()), nil
+	default:
+		return nil, fmt.Errorf("unknown trigger kind: %s", t.Kind)
+	}
+}
+
+// Resolve checks a trigger before a job is armed with it and fills in what
+// the job should remember about its target, such as the name of a watched PID
+func Resolve(t config.Trigger) (config.Trigger, error) {
+	switch t.Kind {
+	case config.TriggerProcess:
+		return resolveProcess(t, NewProcessSource())
+	case config.TriggerNetwork:
+		// Fail early on an unknown interface
+		_, err := NewNetworkSource().Bytes(t.Interface)
+		return t, err
+	case config.TriggerLoad:
+		_, err := NewLoadSource().LoadAverage()
+		return t, err
+	case config.TriggerCPU:
+		_, _, err := NewLoadSource().CPUTimes()
+		return t, err
+	case config.TriggerMedia:
+		return resolveMedia(t, NewMediaSource())
+	case config.TriggerBattery:
+		// Fail early on machines without a battery
+		_, err := NewBatterySource().Power()
+		return t, err
+	default:
+		return t, nil
+	}
+}
+
+// Rearms reports whether a running countdown goes back to waiting once the
+// condition of t is no longer met
+func Rearms(t config.Trigger) bool {
+	switch t.Kind {
+	case config.TriggerIdle, config.TriggerNetwork, config.TriggerLoad, config.TriggerCPU:
+		return true
+	case config.TriggerMedia:
+		// Playback that resumes stops the countdown, the next episode starting
+		// after the armed one does not
+		return t.Media != config.MediaTrack
+	case config.TriggerBattery:
+		return t.KeepOnAC
+	default:
+		return false
+	}
+}
+
+// Cancels reports whether a running countdown cancels the job once the
+// condition of t is no longer met, like a low battery once AC returns
+func Cancels(t config.Trigger) bool {
+	return t.Kind == config.TriggerBattery && !t.KeepOnAC
+}
+
+// Describe returns a short description of t, e.g. "idle 10m" or "exit of rsync"
+func Describe(t config.Trigger) string {
+	switch t.Kind {
+	case config.TriggerIdle:
+		return "idle " + formatSpan(time.Duration(t.IdleSeconds)*time.Second)
+	case config.TriggerProcess:
+		if t.PID > 0 {
+			return "exit of " + describeProcess(t.PID, t.Process)
+		}
+		return "exit of " + t.Process
+	case config.TriggerNetwork:
+		name := t.Interface
+		if name == "" {
+			name = "network"
+		}
+		return fmt.Sprintf("%s below %s for %s", name, utils.FormatRate(float64(t.RateBytes)), formatSpan(time.Duration(t.QuietSeconds)*time.Second))
+	case config.TriggerLoad:
+		return fmt.Sprintf("load below %g for %s", t.LoadBelow, formatSpan(time.Duration(t.QuietSeconds)*time.Second))
+	case config.TriggerCPU:
+		return fmt.Sprintf("cpu below %g%% for %s", t.CPUBelow, formatSpan(time.Duration(t.QuietSeconds)*time.Second))
+	case config.TriggerMedia:
+		what := "end of playback"
+		if t.Media == config.MediaTrack {
+			what = "end of the current track"
+		}
+		if t.Player != "" {
+			what += " on " + t.Player
+		}
+		return what
+	case config.TriggerBattery:
+		return fmt.Sprintf("battery below %d%%", t.BatteryBelow)
+	default:
+		return t.Kind
+	}
+}
+
+// formatSpan formats d in whole minutes like other durations in gts
+func formatSpan(d time.Duration) string {
+	return utils.FormatDuration(int(d.Minutes()))
+}
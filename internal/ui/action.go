@@ -0,0 +1,19 @@
+package ui
+
+import (
+	"github.com/kaganyuksek/gotosleep/internal/i18n"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+)
+
+// actionName returns the translated display name of a power action
+func actionName(action shutdown.Action) string {
+	return i18n.T("power_actions." + string(action))
+}
+
+// activeTitle returns the countdown title for a power action
+func activeTitle(action shutdown.Action) string {
+	if action == shutdown.ActionPowerOff {
+		return i18n.T("active.title")
+	}
+	return i18n.T("active.title_" + string(action))
+}
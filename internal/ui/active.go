@@ -5,10 +5,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kaganyuksek/gotosleep/internal/config"
 	"github.com/kaganyuksek/gotosleep/internal/i18n"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+	"github.com/kaganyuksek/gotosleep/internal/trigger"
 	"github.com/kaganyuksek/gotosleep/internal/utils"
 )
 
@@ -23,25 +26,30 @@ type ActiveModel struct {
 	startTime time.Time
 	endTime   time.Time
 	duration  time.Duration
+	action    shutdown.Action
+	waiting   bool            // the job waits for its trigger, nothing counts down yet
+	trigger   *config.Trigger // nil for plain timers
+	detail    string          // latest trigger reading reported by the daemon
+	adjusting bool            // true while the custom adjustment input is open
+	input     textinput.Model
+	pending   time.Duration
+	err       string
 }
 
 // NewActiveModel creates a new active model
 func NewActiveModel(cfg *config.Config) ActiveModel {
-	var startTime, endTime time.Time
-	var duration time.Duration
+	ti := textinput.New()
+	ti.Placeholder = i18n.T("active.adjust_placeholder")
+	ti.CharLimit = 10
+	ti.Width = 15
 
-	if cfg.ActiveJob != nil {
-		startTime = cfg.ActiveJob.StartTime
-		endTime = cfg.ActiveJob.EndTime
-		duration = endTime.Sub(startTime)
-	}
-
-	return ActiveModel{
-		config:    cfg,
-		startTime: startTime,
-		endTime:   endTime,
-		duration:  duration,
+	m := ActiveModel{
+		config: cfg,
+		action: shutdown.ActionPowerOff,
+		input:  ti,
 	}
+	m.Refresh(cfg)
+	return m
 }
 
 // Init initializes the active model
@@ -67,13 +75,53 @@ func (m ActiveModel) Update(msg tea.Msg) (ActiveModel, tea.Cmd) {
 	case TickMsg:
 		// Continue ticking
 		return m, tick()
+
+	case tea.KeyMsg:
+		if m.adjusting {
+			switch msg.String() {
+			case "enter":
+				delta, err := utils.ParseAdjustment(m.input.Value())
+				if err != nil {
+					m.err = err.Error()
+					return m, nil
+				}
+				m.pending = delta
+				m.stopAdjusting()
+				return m, nil
+			case "esc":
+				m.stopAdjusting()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+
+		if msg.String() == "x" {
+			m.adjusting = true
+			m.err = ""
+			m.input.Focus()
+			return m, textinput.Blink
+		}
 	}
 
 	return m, nil
 }
 
+// stopAdjusting closes the custom adjustment input
+func (m *ActiveModel) stopAdjusting() {
+	m.adjusting = false
+	m.err = ""
+	m.input.Blur()
+	m.input.SetValue("")
+}
+
 // View renders the active countdown screen
 func (m ActiveModel) View() string {
+	if m.waiting {
+		return m.viewWaiting()
+	}
+
 	var s strings.Builder
 
 	// Calculate remaining time
@@ -83,8 +131,8 @@ func (m ActiveModel) View() string {
 		remaining = 0
 	}
 
-	// Calculate progress percentage
-	elapsed := now.Sub(m.startTime)
+	// Calculate progress percentage, triggered jobs only count down after waiting
+	elapsed := m.duration - remaining
 	if elapsed < 0 {
 		elapsed = 0
 	}
@@ -94,7 +142,7 @@ func (m ActiveModel) View() string {
 	}
 
 	// Title
-	title := BigTitleStyle.Render(i18n.T("active.title"))
+	title := BigTitleStyle.Render(activeTitle(m.action))
 	s.WriteString(title + "\n\n")
 
 	// Calculate dynamic widths based on content area (inside the border)
@@ -134,14 +182,53 @@ func (m ActiveModel) View() string {
 		m.startTime.Format("15:04:05"),
 		i18n.T("active.scheduled"),
 		m.endTime.Format("15:04:05"))
-	s.WriteString(StatusStyle.Render(info) + "\n\n")
+	s.WriteString(StatusStyle.Render(info) + "\n")
+
+	// Condition that started the countdown, it may still stop it
+	if m.trigger != nil {
+		line := fmt.Sprintf("%s: %s", i18n.T("active.trigger"), trigger.Describe(*m.trigger))
+		if m.detail != "" {
+			line += "  (" + m.detail + ")"
+		}
+		s.WriteString(StatusStyle.Render(line) + "\n")
+	}
+
+	// Net adjustment recorded on the job's history entry
+	if adjusted := m.adjustedBy(); adjusted != 0 {
+		sign := "+"
+		if adjusted < 0 {
+			sign = "-"
+			adjusted = -adjusted
+		}
+		s.WriteString(StatusStyle.Render(fmt.Sprintf("%s: %s%s",
+			i18n.T("active.adjusted"), sign, utils.FormatDuration(int(adjusted.Minutes())))) + "\n")
+	}
+	s.WriteString("\n")
+
+	// Custom adjustment input
+	if m.adjusting {
+		s.WriteString(TitleStyle.Render(i18n.T("active.adjust_label")+":") + " " + m.input.View() + "\n\n")
+	}
+
+	// Error message
+	if m.err != "" {
+		s.WriteString(ErrorStyle.Render(i18n.T("home.error")+": "+m.err) + "\n\n")
+	}
 
 	// Actions
 	help := ""
-	help += KeyStyle.Render("c") + " " + i18n.T("active.cancel") + "   "
-	help += KeyStyle.Render("e") + " " + i18n.T("active.edit") + "   "
-	help += KeyStyle.Render(i18n.T("keys.history")) + " " + i18n.T("actions.history") + "   "
-	help += KeyStyle.Render(i18n.T("keys.esc")) + " " + i18n.T("actions.back")
+	if m.adjusting {
+		help += KeyStyle.Render(i18n.T("keys.enter")) + " " + i18n.T("active.adjust") + "   "
+		help += KeyStyle.Render(i18n.T("keys.esc")) + " " + i18n.T("actions.cancel")
+	} else {
+		help += KeyStyle.Render("+/-") + " " + i18n.T("active.extend") + "/" + i18n.T("active.shorten") + " 5m   "
+		help += KeyStyle.Render("]/[") + " " + i18n.T("active.extend") + "/" + i18n.T("active.shorten") + " 15m   "
+		help += KeyStyle.Render("x") + " " + i18n.T("active.adjust") + "   "
+		help += KeyStyle.Render("c") + " " + i18n.T("active.cancel") + "   "
+		help += KeyStyle.Render("e") + " " + i18n.T("active.edit") + "   "
+		help += KeyStyle.Render(i18n.T("keys.history")) + " " + i18n.T("actions.history") + "   "
+		help += KeyStyle.Render(i18n.T("keys.esc")) + " " + i18n.T("actions.back")
+	}
 	s.WriteString(HelpStyle.Render(help))
 
 	// Wrap in box with responsive width
@@ -150,12 +237,101 @@ func (m ActiveModel) View() string {
 	return content
 }
 
+// viewWaiting renders a triggered job that waits for its condition
+func (m ActiveModel) viewWaiting() string {
+	var s strings.Builder
+
+	s.WriteString(BigTitleStyle.Render(i18n.T("active.waiting_"+m.trigger.Kind)) + "\n\n")
+
+	contentAreaWidth := max(m.width-8, 40)
+	detail := m.detail
+	if detail == "" {
+		detail = trigger.Describe(*m.trigger)
+	}
+	s.WriteString(lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#7D56F4")).
+		Bold(true).
+		Align(lipgloss.Center).
+		Width(contentAreaWidth).
+		Padding(1).
+		Render(detail))
+	s.WriteString("\n\n")
+
+	info := fmt.Sprintf("%s: %s  →  %s: %s %s",
+		i18n.T("active.started"),
+		m.startTime.Format("15:04:05"),
+		actionName(m.action),
+		utils.FormatDuration(int(m.duration.Minutes())),
+		i18n.T("active.after_trigger"))
+	s.WriteString(StatusStyle.Render(info) + "\n")
+	s.WriteString(StatusStyle.Render(fmt.Sprintf("%s: %s", i18n.T("active.trigger"), trigger.Describe(*m.trigger))) + "\n\n")
+
+	if m.err != "" {
+		s.WriteString(ErrorStyle.Render(i18n.T("home.error")+": "+m.err) + "\n\n")
+	}
+
+	help := ""
+	help += KeyStyle.Render("c") + " " + i18n.T("active.cancel") + "   "
+	help += KeyStyle.Render(i18n.T("keys.history")) + " " + i18n.T("actions.history") + "   "
+	help += KeyStyle.Render(i18n.T("keys.esc")) + " " + i18n.T("actions.back")
+	s.WriteString(HelpStyle.Render(help))
+
+	contentWidth := max(m.width-2, 40)
+	return BaseStyle.Width(contentWidth).Render(s.String())
+}
+
+// adjustedBy returns the net adjustment of the active job
+func (m ActiveModel) adjustedBy() time.Duration {
+	if m.config.ActiveJob == nil || len(m.config.History) == 0 {
+		return 0
+	}
+
+	var total time.Duration
+	for _, adj := range m.config.History[0].Adjustments {
+		total += time.Duration(adj.DeltaSeconds) * time.Second
+	}
+	return total
+}
+
+// IsAdjusting returns true while the custom adjustment input is open
+func (m ActiveModel) IsAdjusting() bool {
+	return m.adjusting
+}
+
+// PendingAdjustment returns a custom adjustment entered by the user
+func (m ActiveModel) PendingAdjustment() (time.Duration, bool) {
+	return m.pending, m.pending != 0
+}
+
+// ClearAdjustment clears the pending custom adjustment
+func (m *ActiveModel) ClearAdjustment() {
+	m.pending = 0
+}
+
+// SetError shows an error on the active screen, empty to clear it
+func (m *ActiveModel) SetError(err string) {
+	m.err = err
+}
+
+// IsWaiting returns true while the job waits for its trigger
+func (m ActiveModel) IsWaiting() bool {
+	return m.waiting
+}
+
+// SetTriggerDetail shows the latest trigger reading reported by the daemon
+func (m *ActiveModel) SetTriggerDetail(detail string) {
+	m.detail = detail
+}
+
 // Refresh updates the active model with latest config
 func (m *ActiveModel) Refresh(cfg *config.Config) {
 	m.config = cfg
-	if cfg.ActiveJob != nil {
-		m.startTime = cfg.ActiveJob.StartTime
-		m.endTime = cfg.ActiveJob.EndTime
-		m.duration = m.endTime.Sub(m.startTime)
+	if job := cfg.ActiveJob; job != nil {
+		m.startTime = job.StartTime
+		m.endTime = job.EndTime
+		m.duration = time.Duration(job.DurationSec) * time.Second
+		m.action = shutdown.ActionOrDefault(job.Action)
+		m.trigger = job.Trigger
+		m.waiting = job.Waiting && job.Trigger != nil
 	}
 }
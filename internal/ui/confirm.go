@@ -1,20 +1,31 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/kaganyuksek/gotosleep/internal/config"
 	"github.com/kaganyuksek/gotosleep/internal/i18n"
+	"github.com/kaganyuksek/gotosleep/internal/schedule"
+	"github.com/kaganyuksek/gotosleep/internal/scheduler"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
 	"github.com/kaganyuksek/gotosleep/internal/utils"
 )
 
 // ConfirmModel represents a confirmation dialog
 type ConfirmModel struct {
 	message   string
-	minutes   int
+	target    utils.Target
+	action    shutdown.Action
 	dryRun    bool
+	windows   []config.Window
+	hooks     []config.Hook
+	hookLead  time.Duration
+	daemon    bool // hooks only run when the daemon owns the job
 	width     int
 	height    int
 	confirmed bool
@@ -22,10 +33,11 @@ type ConfirmModel struct {
 }
 
 // NewConfirmModel creates a new confirm model with dry-run setting from config
-func NewConfirmModel(minutes int, dryRunDefault bool) ConfirmModel {
+func NewConfirmModel(target utils.Target, action shutdown.Action, dryRunDefault bool) ConfirmModel {
 	return ConfirmModel{
 		message:   i18n.T("confirm.title"),
-		minutes:   minutes,
+		target:    target,
+		action:    action,
 		dryRun:    dryRunDefault,
 		confirmed: false,
 		cancelled: false,
@@ -48,6 +60,13 @@ func (m ConfirmModel) Update(msg tea.Msg) (ConfirmModel, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "y", "Y":
+			// A forbidden window refuses the action, only cancelling is left
+			if _, err := m.allowedTime(time.Now()); err != nil {
+				return m, nil
+			}
+			if m.hooksRefused() {
+				return m, nil
+			}
 			m.confirmed = true
 			return m, nil
 		case "n", "N", "esc":
@@ -56,6 +75,9 @@ func (m ConfirmModel) Update(msg tea.Msg) (ConfirmModel, tea.Cmd) {
 		case "d", "D":
 			m.dryRun = !m.dryRun
 			return m, nil
+		case "a", "A":
+			m.action = m.action.Next()
+			return m, nil
 		}
 	}
 
@@ -71,9 +93,56 @@ func (m ConfirmModel) View() string {
 	s.WriteString(title + "\n\n")
 
 	// Message
-	durationStr := utils.FormatDuration(m.minutes)
+	now := time.Now()
+	endTime := m.target.EndTime(now)
+	durationStr := utils.FormatDuration(int(endTime.Sub(now).Minutes()))
 	msg := fmt.Sprintf("%s %s?", i18n.T("confirm.message"), durationStr)
-	s.WriteString(lipgloss.NewStyle().Bold(true).Render(msg) + "\n\n")
+	s.WriteString(lipgloss.NewStyle().Bold(true).Render(msg) + "\n")
+
+	// Resolved wall-clock time, including the date when it is not today
+	scheduledStr := endTime.Format("15:04")
+	if endTime.YearDay() != now.YearDay() || endTime.Year() != now.Year() {
+		scheduledStr = endTime.Format("Mon 2006-01-02 15:04")
+	}
+	s.WriteString(i18n.T("confirm.scheduled_for") + ": " +
+		lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Render(scheduledStr) + "\n")
+
+	// Explain what a forbidden window does to the action
+	if allowed, err := m.allowedTime(now); err != nil {
+		var windowErr *scheduler.WindowError
+		if errors.As(err, &windowErr) {
+			s.WriteString(ErrorStyle.Render(fmt.Sprintf(i18n.T("confirm.window_refused"),
+				schedule.DescribeWindow(windowErr.Window), windowErr.Until.Format("15:04"))) + "\n")
+		} else {
+			s.WriteString(ErrorStyle.Render(i18n.T("home.error")+": "+err.Error()) + "\n")
+		}
+	} else if !allowed.Equal(endTime) {
+		w, _, _ := schedule.Blocking(m.windows, endTime)
+		s.WriteString(WarningStyle.Render(fmt.Sprintf(i18n.T("confirm.window_deferred"),
+			schedule.DescribeWindow(*w), allowed.Format("15:04"))) + "\n")
+	}
+
+	// List the hooks that run before the action
+	if len(m.hooks) > 0 {
+		header := i18n.T("confirm.hooks_run")
+		if m.dryRun {
+			header = i18n.T("confirm.hooks_dry_run")
+		}
+		s.WriteString(fmt.Sprintf(header, m.hookLead) + "\n")
+		for i, hook := range m.hooks {
+			s.WriteString(fmt.Sprintf("  %d. %s: %s\n", i+1, hook.Name, hook.Command))
+		}
+		if m.hooksRefused() {
+			s.WriteString(ErrorStyle.Render(i18n.T("confirm.hooks_need_daemon")) + "\n")
+		}
+	}
+	s.WriteString("\n")
+
+	// Action selector
+	actionLine := i18n.T("power_actions.label") + ": " +
+		lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Render(actionName(m.action)) +
+		"  " + KeyStyle.Render("[A]") + " " + i18n.T("power_actions.change")
+	s.WriteString(actionLine + "\n\n")
 
 	// Options
 	yesBtn := KeyStyle.Render("[Y]") + " " + i18n.T("confirm.yes") + "   "
@@ -100,6 +169,29 @@ func (m ConfirmModel) View() string {
 	return content
 }
 
+// SetWindows sets the forbidden windows the action is checked against
+func (m *ConfirmModel) SetWindows(windows []config.Window) {
+	m.windows = windows
+}
+
+// SetHooks sets the pre-action hooks of the job, daemon tells whether the
+// gts daemon runs them
+func (m *ConfirmModel) SetHooks(settings config.Settings, daemon bool) {
+	m.hooks = settings.Hooks
+	m.hookLead = settings.HookLead()
+	m.daemon = daemon
+}
+
+// hooksRefused reports whether the job would skip its hooks
+func (m ConfirmModel) hooksRefused() bool {
+	return len(m.hooks) > 0 && !m.daemon && !m.dryRun
+}
+
+// allowedTime returns when the action may run given the forbidden windows
+func (m ConfirmModel) allowedTime(now time.Time) (time.Time, error) {
+	return scheduler.CheckWindows(m.windows, m.target.EndTime(now))
+}
+
 // IsConfirmed returns true if the user confirmed
 func (m ConfirmModel) IsConfirmed() bool {
 	return m.confirmed
@@ -110,6 +202,16 @@ func (m ConfirmModel) IsCancelled() bool {
 	return m.cancelled
 }
 
+// Target returns the duration or absolute time being confirmed
+func (m ConfirmModel) Target() utils.Target {
+	return m.target
+}
+
+// Action returns the selected power action
+func (m ConfirmModel) Action() shutdown.Action {
+	return m.action
+}
+
 // IsDryRun returns true if dry-run mode is enabled
 func (m ConfirmModel) IsDryRun() bool {
 	return m.dryRun
@@ -3,17 +3,37 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kaganyuksek/gotosleep/internal/config"
 	"github.com/kaganyuksek/gotosleep/internal/i18n"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
 	"github.com/kaganyuksek/gotosleep/internal/utils"
 )
 
+// historyStatuses are the statuses the status filter cycles through
+var historyStatuses = []string{
+	config.StatusOK,
+	config.StatusCancelled,
+	config.StatusFailed,
+	config.StatusDryRun,
+	config.StatusMissed,
+	config.StatusAborted,
+	config.StatusSkipped,
+}
+
+// historyPeriods are the days the period filter cycles through, 0 for all
+var historyPeriods = []int{0, 1, 7, 30}
+
 // HistoryModel represents the history screen
 type HistoryModel struct {
 	config       *config.Config
+	entries      []config.History // entries matching the filters
+	status       int              // index into historyStatuses plus one, 0 for all
+	action       int              // index into shutdown.Actions plus one, 0 for all
+	period       int              // index into historyPeriods
 	width        int
 	height       int
 	selectedItem int
@@ -22,11 +42,45 @@ type HistoryModel struct {
 
 // NewHistoryModel creates a new history model
 func NewHistoryModel(cfg *config.Config) HistoryModel {
-	return HistoryModel{
+	m := HistoryModel{
 		config:       cfg,
 		selectedItem: 0,
 		scrollOffset: 0,
 	}
+	m.filter()
+	return m
+}
+
+// query returns the history query of the selected filters
+func (m HistoryModel) query(now time.Time) config.HistoryQuery {
+	var q config.HistoryQuery
+	if m.status > 0 {
+		q.Statuses = []string{historyStatuses[m.status-1]}
+	}
+	if m.action > 0 {
+		q.Actions = []string{string(shutdown.Actions[m.action-1])}
+	}
+	if days := historyPeriods[m.period]; days > 0 {
+		// Today, or today and the days before it
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		q.Since = today.AddDate(0, 0, 1-days)
+	}
+	return q
+}
+
+// filter selects the entries matching the filters and keeps the selection in range
+func (m *HistoryModel) filter() {
+	m.entries = m.config.QueryHistory(m.query(time.Now()))
+	if m.selectedItem >= len(m.entries) && len(m.entries) > 0 {
+		m.selectedItem = len(m.entries) - 1
+	}
+	if len(m.entries) == 0 {
+		m.selectedItem = 0
+		m.scrollOffset = 0
+	}
+	if m.scrollOffset > m.selectedItem {
+		m.scrollOffset = m.selectedItem
+	}
 }
 
 // Init initializes the history model
@@ -43,7 +97,23 @@ func (m HistoryModel) Update(msg tea.Msg) (HistoryModel, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
-		historyLen := len(m.config.History)
+		// Filters cycle through their values, back to all after the last
+		switch msg.String() {
+		case "f":
+			m.status = (m.status + 1) % (len(historyStatuses) + 1)
+			m.filter()
+			return m, nil
+		case "a":
+			m.action = (m.action + 1) % (len(shutdown.Actions) + 1)
+			m.filter()
+			return m, nil
+		case "t":
+			m.period = (m.period + 1) % len(historyPeriods)
+			m.filter()
+			return m, nil
+		}
+
+		historyLen := len(m.entries)
 		if historyLen == 0 {
 			return m, nil
 		}
@@ -93,20 +163,27 @@ func (m HistoryModel) View() string {
 	title := BigTitleStyle.Render(i18n.T("history.title"))
 	s.WriteString(title + "\n\n")
 
+	// Filters are only shown when there is history to filter
+	if len(m.config.History) > 0 {
+		s.WriteString(m.filterLine() + "\n\n")
+	}
+
 	// Check if history is empty
 	if len(m.config.History) == 0 {
 		s.WriteString(StatusStyle.Render(i18n.T("history.empty")) + "\n\n")
+	} else if len(m.entries) == 0 {
+		s.WriteString(StatusStyle.Render(i18n.T("history.no_matches")) + "\n\n")
 	} else {
 		// Display history items
 		visibleItems := 10 // Show 10 items max
 		start := m.scrollOffset
 		end := start + visibleItems
-		if end > len(m.config.History) {
-			end = len(m.config.History)
+		if end > len(m.entries) {
+			end = len(m.entries)
 		}
 
 		for i := start; i < end; i++ {
-			h := m.config.History[i]
+			h := m.entries[i]
 
 			// Format date and time
 			dateStr := h.CreatedAt.Format("2006-01-02 15:04")
@@ -115,7 +192,15 @@ func (m HistoryModel) View() string {
 			durationStr := utils.FormatDuration(h.DurationSeconds / 60)
 
 			// Format scheduled time
-			scheduledStr := h.ScheduledFor.Format("15:04")
+			scheduledStr := "-"
+			if !h.ScheduledFor.IsZero() {
+				scheduledStr = h.ScheduledFor.Format("15:04")
+			}
+
+			// Only non-default actions are worth calling out
+			if action := shutdown.ActionOrDefault(h.Action); action != shutdown.ActionPowerOff {
+				scheduledStr += " " + lipgloss.NewStyle().Foreground(dimColor).Render(actionName(action))
+			}
 
 			// Format status with color
 			statusStr := ""
@@ -128,6 +213,12 @@ func (m HistoryModel) View() string {
 				statusStr = lipgloss.NewStyle().Foreground(errorColor).Render(i18n.T("history.status_failed"))
 			case config.StatusDryRun:
 				statusStr = lipgloss.NewStyle().Foreground(dimColor).Render(i18n.T("history.status_dry_run"))
+			case config.StatusMissed:
+				statusStr = lipgloss.NewStyle().Foreground(errorColor).Render(i18n.T("history.status_missed"))
+			case config.StatusAborted:
+				statusStr = lipgloss.NewStyle().Foreground(warningColor).Render(i18n.T("history.status_aborted"))
+			case config.StatusSkipped:
+				statusStr = lipgloss.NewStyle().Foreground(dimColor).Render(i18n.T("history.status_skipped"))
 			default:
 				statusStr = h.Status
 			}
@@ -151,11 +242,11 @@ func (m HistoryModel) View() string {
 		}
 
 		// Show scroll indicator if needed
-		if len(m.config.History) > visibleItems {
+		if len(m.entries) > visibleItems {
 			indicator := fmt.Sprintf("\n%s (%d/%d)",
 				StatusStyle.Render(i18n.T("history.scroll_indicator")),
 				m.selectedItem+1,
-				len(m.config.History),
+				len(m.entries),
 			)
 			s.WriteString(indicator + "\n")
 		}
@@ -165,10 +256,15 @@ func (m HistoryModel) View() string {
 
 	// Actions
 	help := ""
-	if len(m.config.History) > 0 {
+	if len(m.entries) > 0 {
 		help += KeyStyle.Render(i18n.T("keys.enter")) + " " + i18n.T("actions.restart") + "   "
 		help += KeyStyle.Render("d") + " " + i18n.T("actions.delete") + "   "
 	}
+	if len(m.config.History) > 0 {
+		help += KeyStyle.Render("f") + " " + i18n.T("history.filter_status") + "   "
+		help += KeyStyle.Render("a") + " " + i18n.T("history.filter_action") + "   "
+		help += KeyStyle.Render("t") + " " + i18n.T("history.filter_period") + "   "
+	}
 	help += KeyStyle.Render(i18n.T("keys.esc")) + " " + i18n.T("actions.back")
 	s.WriteString(HelpStyle.Render(help))
 
@@ -178,10 +274,38 @@ func (m HistoryModel) View() string {
 	return content
 }
 
+// filterLine renders the selected filters
+func (m HistoryModel) filterLine() string {
+	status := i18n.T("history.all")
+	if m.status > 0 {
+		status = i18n.T("history.status_" + strings.ReplaceAll(historyStatuses[m.status-1], "-", "_"))
+	}
+	action := i18n.T("history.all")
+	if m.action > 0 {
+		action = actionName(shutdown.Actions[m.action-1])
+	}
+	period := i18n.T("history.all")
+	switch historyPeriods[m.period] {
+	case 1:
+		period = i18n.T("history.period_today")
+	case 7:
+		period = i18n.T("history.period_week")
+	case 30:
+		period = i18n.T("history.period_month")
+	}
+
+	dim := lipgloss.NewStyle().Foreground(dimColor)
+	return fmt.Sprintf("%s %s   %s %s   %s %s",
+		dim.Render(i18n.T("history.filter_status")+":"), status,
+		dim.Render(i18n.T("history.filter_action")+":"), action,
+		dim.Render(i18n.T("history.filter_period")+":"), period,
+	)
+}
+
 // GetSelectedHistory returns the currently selected history item
 func (m HistoryModel) GetSelectedHistory() *config.History {
-	if m.selectedItem >= 0 && m.selectedItem < len(m.config.History) {
-		return &m.config.History[m.selectedItem]
+	if m.selectedItem >= 0 && m.selectedItem < len(m.entries) {
+		return &m.entries[m.selectedItem]
 	}
 	return nil
 }
@@ -190,11 +314,5 @@ func (m HistoryModel) GetSelectedHistory() *config.History {
 func (m *HistoryModel) Refresh(cfg *config.Config) {
 	m.config = cfg
 	// Adjust selection if history changed
-	if m.selectedItem >= len(cfg.History) && len(cfg.History) > 0 {
-		m.selectedItem = len(cfg.History) - 1
-	}
-	if len(cfg.History) == 0 {
-		m.selectedItem = 0
-		m.scrollOffset = 0
-	}
+	m.filter()
 }
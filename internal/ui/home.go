@@ -3,11 +3,15 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/kaganyuksek/gotosleep/internal/config"
 	"github.com/kaganyuksek/gotosleep/internal/i18n"
+	"github.com/kaganyuksek/gotosleep/internal/schedule"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
 	"github.com/kaganyuksek/gotosleep/internal/utils"
 )
 
@@ -82,7 +86,7 @@ func (m HomeModel) Update(msg tea.Msg) (HomeModel, tea.Cmd) {
 			}
 
 			if m.input.Value() != "" {
-				_, err := utils.ParseDuration(m.input.Value())
+				_, err := utils.ParseTarget(m.input.Value(), time.Now())
 				if err != nil {
 					m.err = err.Error()
 					return m, nil
@@ -117,12 +121,22 @@ func (m HomeModel) View() string {
 
 	// Status
 	status := i18n.T("home.status") + ": "
-	if m.config.ActiveJob != nil {
+	if job := m.config.ActiveJob; job != nil && job.Waiting {
+		status += StatusActiveStyle.Render(i18n.T("home.status_waiting"))
+	} else if job != nil {
 		status += StatusActiveStyle.Render(i18n.T("home.status_active"))
 	} else {
 		status += StatusStyle.Render(i18n.T("home.status_inactive"))
 	}
-	s.WriteString(status + "\n\n")
+	s.WriteString(status + "\n")
+
+	// Next recurring schedule
+	if sched, at := schedule.Upcoming(m.config.Schedules, time.Now()); sched != nil {
+		next := fmt.Sprintf(i18n.T("home.next_schedule"),
+			at.Local().Format("Mon 15:04"), actionName(shutdown.ActionOrDefault(sched.Action)))
+		s.WriteString(StatusStyle.Render(next) + "\n")
+	}
+	s.WriteString("\n")
 
 	// Quick presets
 	s.WriteString(TitleStyle.Render(i18n.T("home.quick_presets")+":") + "\n")
@@ -133,6 +147,9 @@ func (m HomeModel) View() string {
 	for i, preset := range m.config.Presets {
 		key := PresetKeyStyle.Render(fmt.Sprintf("[%d]", i+1))
 		label := preset.Label
+		if action := shutdown.ActionOrDefault(preset.Action); action != shutdown.ActionPowerOff {
+			label += " · " + actionName(action)
+		}
 
 		var item string
 		if m.selectedPreset == i {
@@ -142,7 +159,7 @@ func (m HomeModel) View() string {
 		}
 
 		// Estimate width (rough calculation, key + label + space)
-		itemWidth := len(fmt.Sprintf("[%d]", i+1)) + len(preset.Label) + 2
+		itemWidth := len(fmt.Sprintf("[%d]", i+1)) + lipgloss.Width(label) + 2
 
 		// Check if adding this item would exceed line width
 		if currentLineWidth > 0 && currentLineWidth+itemWidth > maxLineWidth {
@@ -174,6 +191,7 @@ func (m HomeModel) View() string {
 	help += KeyStyle.Render(i18n.T("keys.enter")) + " " + i18n.T("actions.start") + "   "
 	help += KeyStyle.Render(i18n.T("keys.tab")) + " " + i18n.T("actions.toggle_input") + "   "
 	help += KeyStyle.Render(i18n.T("keys.history")) + " " + i18n.T("actions.history") + "   "
+	help += KeyStyle.Render(i18n.T("keys.schedules")) + " " + i18n.T("actions.schedules") + "   "
 	help += KeyStyle.Render(i18n.T("keys.settings")) + " " + i18n.T("actions.settings") + "   "
 	if m.config.ActiveJob != nil {
 		help += KeyStyle.Render(i18n.T("keys.active")) + " " + i18n.T("actions.active") + "   "
@@ -187,17 +205,35 @@ func (m HomeModel) View() string {
 	return content
 }
 
-// GetSelectedDuration returns the selected duration in minutes
-func (m HomeModel) GetSelectedDuration() (int, error) {
+// GetSelectedTarget returns the selected duration or absolute time
+func (m HomeModel) GetSelectedTarget() (utils.Target, error) {
 	if m.selectedPreset >= 0 && m.selectedPreset < len(m.config.Presets) {
-		return m.config.Presets[m.selectedPreset].Minutes, nil
+		return utils.Target{Minutes: m.config.Presets[m.selectedPreset].Minutes}, nil
 	}
 
 	if m.input.Value() != "" {
-		return utils.ParseDuration(m.input.Value())
+		return utils.ParseTarget(m.input.Value(), time.Now())
 	}
 
-	return 0, fmt.Errorf("no duration selected")
+	return utils.Target{}, fmt.Errorf("no duration selected")
+}
+
+// GetSelectedAction returns the power action of the selected preset
+func (m HomeModel) GetSelectedAction() shutdown.Action {
+	if m.selectedPreset >= 0 && m.selectedPreset < len(m.config.Presets) {
+		return shutdown.ActionOrDefault(m.config.Presets[m.selectedPreset].Action)
+	}
+	return shutdown.ActionPowerOff
+}
+
+// IsInputFocused reports whether typed keys go to the duration input
+func (m HomeModel) IsInputFocused() bool {
+	return m.input.Focused()
+}
+
+// SetError shows an error below the input, e.g. a refused start
+func (m *HomeModel) SetError(err string) {
+	m.err = err
 }
 
 // Reset resets the selection
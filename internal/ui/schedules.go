@@ -0,0 +1,414 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kaganyuksek/gotosleep/internal/config"
+	"github.com/kaganyuksek/gotosleep/internal/i18n"
+	"github.com/kaganyuksek/gotosleep/internal/schedule"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
+	"github.com/kaganyuksek/gotosleep/internal/utils"
+)
+
+// Fields of the schedule edit form, in tab order
+const (
+	scheduleFieldDays = iota
+	scheduleFieldTime
+	scheduleFieldLead
+	scheduleFieldTimezone
+	scheduleFieldCount
+)
+
+// previewRuns is how many upcoming runs are previewed
+const previewRuns = 5
+
+// SchedulesModel represents the recurring schedules screen
+type SchedulesModel struct {
+	config       *config.Config
+	width        int
+	height       int
+	selectedItem int
+	editing      bool
+	editingID    string // empty while adding a new schedule
+	field        int
+	inputs       [scheduleFieldCount]textinput.Model
+	err          string
+}
+
+// NewSchedulesModel creates a new schedules model
+func NewSchedulesModel(cfg *config.Config) SchedulesModel {
+	placeholders := [scheduleFieldCount]string{
+		i18n.T("schedules.days_placeholder"),
+		i18n.T("schedules.time_placeholder"),
+		i18n.T("schedules.lead_placeholder"),
+		i18n.T("schedules.timezone_placeholder"),
+	}
+
+	var inputs [scheduleFieldCount]textinput.Model
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].CharLimit = 30
+		inputs[i].Width = 30
+		inputs[i].Placeholder = placeholders[i]
+	}
+	// Room for cron expressions
+	inputs[scheduleFieldDays].CharLimit = 60
+
+	return SchedulesModel{
+		config: cfg,
+		inputs: inputs,
+	}
+}
+
+// Init initializes the schedules model
+func (m SchedulesModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages for the schedules screen
+func (m SchedulesModel) Update(msg tea.Msg) (SchedulesModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.editing {
+			switch msg.String() {
+			case "enter":
+				// Move to the next field, the last one saves
+				if m.field < scheduleFieldCount-1 {
+					return m, m.focus(m.field + 1)
+				}
+				if err := m.save(); err != nil {
+					m.err = err.Error()
+					return m, nil
+				}
+				m.stopEditing()
+				return m, nil
+			case "tab":
+				return m, m.focus((m.field + 1) % scheduleFieldCount)
+			case "shift+tab":
+				return m, m.focus((m.field + scheduleFieldCount - 1) % scheduleFieldCount)
+			case "esc":
+				m.stopEditing()
+				return m, nil
+			}
+
+			m.inputs[m.field], cmd = m.inputs[m.field].Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.selectedItem > 0 {
+				m.selectedItem--
+				m.err = ""
+			}
+
+		case "down", "j":
+			if m.selectedItem < len(m.config.Schedules)-1 {
+				m.selectedItem++
+				m.err = ""
+			}
+
+		case "n":
+			// Add a new schedule
+			m.startEditing(config.Schedule{
+				Days:        []time.Weekday{1, 2, 3, 4, 5},
+				Time:        "23:00",
+				LeadMinutes: config.DefaultScheduleLeadMinutes,
+			})
+			return m, textinput.Blink
+
+		case "enter":
+			if s := m.selected(); s != nil {
+				m.startEditing(*s)
+				return m, textinput.Blink
+			}
+
+		case " ":
+			if s := m.selected(); s != nil {
+				s.Enabled = !s.Enabled
+			}
+
+		case "a":
+			// Cycle the power action of the selected schedule
+			if s := m.selected(); s != nil {
+				s.Action = string(shutdown.ActionOrDefault(s.Action).Next())
+			}
+
+		case "d":
+			if s := m.selected(); s != nil {
+				m.config.DeleteSchedule(s.ID)
+				if m.selectedItem >= len(m.config.Schedules) && m.selectedItem > 0 {
+					m.selectedItem--
+				}
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// View renders the schedules screen
+func (m SchedulesModel) View() string {
+	var s strings.Builder
+
+	// Title
+	title := BigTitleStyle.Render(i18n.T("schedules.title"))
+	s.WriteString(title + "\n\n")
+
+	if len(m.config.Schedules) == 0 {
+		s.WriteString(StatusStyle.Render(i18n.T("schedules.empty")) + "\n")
+	}
+
+	now := time.Now()
+	for i, sched := range m.config.Schedules {
+		line := fmt.Sprintf("%s  %s · %s · %s",
+			m.formatEnabled(sched.Enabled),
+			schedule.Describe(sched),
+			actionName(shutdown.ActionOrDefault(sched.Action)),
+			fmt.Sprintf(i18n.T("schedules.lead"), utils.FormatDuration(int(schedule.Lead(sched).Minutes()))),
+		)
+		if sched.DryRun {
+			line += " · " + i18n.T("confirm.dry_run")
+		}
+		if sched.Name != "" {
+			line += " · " + sched.Name
+		}
+		if sched.Enabled {
+			if at, err := schedule.Pending(sched, now); err == nil {
+				line += "  " + StatusStyle.Render(fmt.Sprintf(i18n.T("schedules.next"), at.Format("Mon 15:04 MST")))
+			}
+		}
+
+		if i == m.selectedItem && !m.editing {
+			line = ListItemSelectedStyle.Render("▶ " + line)
+		} else {
+			line = ListItemStyle.Render("  " + line)
+		}
+		s.WriteString(line + "\n")
+	}
+
+	// Show edit form if editing
+	if m.editing {
+		s.WriteString("\n")
+		heading := i18n.T("schedules.edit")
+		if m.editingID == "" {
+			heading = i18n.T("schedules.add")
+		}
+		s.WriteString(TitleStyle.Render(heading) + "\n")
+
+		labels := [scheduleFieldCount]string{
+			i18n.T("schedules.days_label"),
+			i18n.T("schedules.time_label"),
+			i18n.T("schedules.lead_label"),
+			i18n.T("schedules.timezone_label"),
+		}
+		for i, input := range m.inputs {
+			line := labels[i] + ": " + input.View()
+			if i == m.field {
+				line = ListItemSelectedStyle.Render("▶ " + line)
+			} else {
+				line = ListItemStyle.Render("  " + line)
+			}
+			s.WriteString(line + "\n")
+		}
+	}
+
+	// Preview the runs of the form, or of the highlighted schedule
+	if preview, ok := m.preview(); ok {
+		s.WriteString("\n" + TitleStyle.Render(i18n.T("schedules.next_runs")) + "\n")
+		runs, err := schedule.Runs(preview, now, previewRuns)
+		for _, at := range runs {
+			s.WriteString(ListItemStyle.Render("  "+at.Format("Mon 2006-01-02 15:04 MST")) + "\n")
+		}
+		if err != nil {
+			s.WriteString(StatusStyle.Render("  "+err.Error()) + "\n")
+		}
+	}
+
+	s.WriteString("\n")
+
+	// Error message
+	if m.err != "" {
+		s.WriteString(ErrorStyle.Render(i18n.T("home.error")+": "+m.err) + "\n\n")
+	}
+
+	// Actions
+	help := ""
+	if m.editing {
+		if m.field < scheduleFieldCount-1 {
+			help += KeyStyle.Render(i18n.T("keys.enter")) + " " + i18n.T("schedules.next_field") + "   "
+		} else {
+			help += KeyStyle.Render(i18n.T("keys.enter")) + " " + i18n.T("schedules.save") + "   "
+		}
+		help += KeyStyle.Render(i18n.T("keys.tab")) + " " + i18n.T("schedules.switch_field") + "   "
+		help += KeyStyle.Render(i18n.T("keys.esc")) + " " + i18n.T("actions.cancel")
+	} else {
+		help += KeyStyle.Render("n") + " " + i18n.T("schedules.new") + "   "
+		if len(m.config.Schedules) > 0 {
+			help += KeyStyle.Render(i18n.T("keys.enter")) + " " + i18n.T("actions.edit") + "   "
+			help += KeyStyle.Render("Space") + " " + i18n.T("schedules.toggle") + "   "
+			help += KeyStyle.Render("a") + " " + i18n.T("settings.cycle_action") + "   "
+			help += KeyStyle.Render("d") + " " + i18n.T("actions.delete") + "   "
+		}
+		help += KeyStyle.Render(i18n.T("keys.esc")) + " " + i18n.T("actions.back")
+	}
+	s.WriteString(HelpStyle.Render(help))
+
+	// Wrap in box with responsive width
+	contentWidth := max(m.width-2, 40)
+	content := BaseStyle.Width(contentWidth).Render(s.String())
+	return content
+}
+
+// IsEditing reports whether the edit form is open
+func (m SchedulesModel) IsEditing() bool {
+	return m.editing
+}
+
+// Refresh updates the schedules model with latest config
+func (m *SchedulesModel) Refresh(cfg *config.Config) {
+	m.config = cfg
+	if m.selectedItem >= len(cfg.Schedules) {
+		m.selectedItem = max(len(cfg.Schedules)-1, 0)
+	}
+}
+
+// selected returns the highlighted schedule, or nil when there is none
+func (m SchedulesModel) selected() *config.Schedule {
+	if m.selectedItem < 0 || m.selectedItem >= len(m.config.Schedules) {
+		return nil
+	}
+	return &m.config.Schedules[m.selectedItem]
+}
+
+// startEditing opens the edit form filled with s
+func (m *SchedulesModel) startEditing(s config.Schedule) {
+	m.editing = true
+	m.editingID = s.ID
+	m.err = ""
+	m.inputs[scheduleFieldDays].SetValue(schedule.FormatDays(s.Days))
+	if s.Cron != "" {
+		m.inputs[scheduleFieldDays].SetValue(s.Cron)
+	}
+	m.inputs[scheduleFieldTime].SetValue(s.Time)
+	m.inputs[scheduleFieldLead].SetValue(utils.FormatDuration(int(schedule.Lead(s).Minutes())))
+	m.inputs[scheduleFieldTimezone].SetValue(s.Timezone)
+	m.focus(scheduleFieldDays)
+}
+
+// stopEditing closes the edit form
+func (m *SchedulesModel) stopEditing() {
+	m.editing = false
+	m.err = ""
+	for i := range m.inputs {
+		m.inputs[i].Blur()
+		m.inputs[i].SetValue("")
+	}
+}
+
+// focus moves the cursor to the given field
+func (m *SchedulesModel) focus(field int) tea.Cmd {
+	m.field = field
+	for i := range m.inputs {
+		if i == field {
+			m.inputs[i].Focus()
+		} else {
+			m.inputs[i].Blur()
+		}
+	}
+	return textinput.Blink
+}
+
+// form builds a schedule from the edit form and returns the field of the
+// first invalid value along with its error
+func (m SchedulesModel) form() (config.Schedule, int, error) {
+	var s config.Schedule
+	when := strings.TrimSpace(m.inputs[scheduleFieldDays].Value())
+	if isCron(when) {
+		s.Cron = when
+	} else {
+		days, err := schedule.ParseDays(when)
+		if err != nil {
+			return s, scheduleFieldDays, err
+		}
+		hour, minute, err := utils.ParseClock(m.inputs[scheduleFieldTime].Value())
+		if err != nil {
+			return s, scheduleFieldTime, err
+		}
+		s.Days, s.Time = days, fmt.Sprintf("%02d:%02d", hour, minute)
+	}
+
+	lead, err := utils.ParseDuration(m.inputs[scheduleFieldLead].Value())
+	if err != nil {
+		return s, scheduleFieldLead, err
+	}
+	s.LeadMinutes = lead
+	s.Timezone = strings.TrimSpace(m.inputs[scheduleFieldTimezone].Value())
+
+	if _, err := schedule.Location(s); err != nil {
+		return s, scheduleFieldTimezone, err
+	}
+	if err := schedule.Validate(s); err != nil {
+		return s, scheduleFieldDays, err
+	}
+	return s, 0, nil
+}
+
+// save validates the form and stores it in the edited or a new schedule
+func (m *SchedulesModel) save() error {
+	form, field, err := m.form()
+	if err != nil {
+		m.focus(field)
+		return err
+	}
+
+	if s := m.config.FindSchedule(m.editingID); s != nil {
+		s.Days, s.Time, s.Cron = form.Days, form.Time, form.Cron
+		s.LeadMinutes, s.Timezone = form.LeadMinutes, form.Timezone
+		return nil
+	}
+
+	form.Enabled = true
+	m.config.AddSchedule(form)
+	m.selectedItem = len(m.config.Schedules) - 1
+	return nil
+}
+
+// preview returns the schedule whose next runs are shown: the edit form
+// while it holds a valid schedule, otherwise the highlighted one
+func (m SchedulesModel) preview() (config.Schedule, bool) {
+	if m.editing {
+		form, _, err := m.form()
+		return form, err == nil
+	}
+	if s := m.selected(); s != nil {
+		return *s, true
+	}
+	return config.Schedule{}, false
+}
+
+// isCron reports whether the days field holds a cron expression or macro
+// rather than a list of days
+func isCron(input string) bool {
+	return strings.HasPrefix(input, "@") || len(strings.Fields(input)) == 5
+}
+
+// formatEnabled formats the enabled state of a schedule with color
+func (m SchedulesModel) formatEnabled(enabled bool) string {
+	if enabled {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575")).Bold(true).Render("ON ")
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("#7D7D7D")).Render("OFF")
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kaganyuksek/gotosleep/internal/config"
 	"github.com/kaganyuksek/gotosleep/internal/i18n"
+	"github.com/kaganyuksek/gotosleep/internal/shutdown"
 )
 
 // SettingsModel represents the settings screen
@@ -171,6 +172,14 @@ func (m SettingsModel) Update(msg tea.Msg) (SettingsModel, tea.Cmd) {
 				}
 			}
 			m.err = ""
+
+		case "a":
+			// Cycle the power action of the selected preset
+			presetIndex := m.selectedItem - 3
+			if presetIndex >= 0 && presetIndex < len(m.config.Presets) {
+				preset := &m.config.Presets[presetIndex]
+				preset.Action = string(shutdown.ActionOrDefault(preset.Action).Next())
+			}
 		}
 	}
 
@@ -213,7 +222,7 @@ func (m SettingsModel) View() string {
 	// Display presets
 	for i, preset := range m.config.Presets {
 		itemIndex := 3 + i
-		line := fmt.Sprintf("%s → %d min", preset.Label, preset.Minutes)
+		line := fmt.Sprintf("%s → %d min · %s", preset.Label, preset.Minutes, actionName(shutdown.ActionOrDefault(preset.Action)))
 
 		if itemIndex == m.selectedItem && !m.editing {
 			line = ListItemSelectedStyle.Render("▶ " + line)
@@ -266,6 +275,9 @@ func (m SettingsModel) View() string {
 	} else {
 		help += KeyStyle.Render(i18n.T("keys.up")+i18n.T("keys.down")) + " Navigate   "
 		help += KeyStyle.Render("Space/"+i18n.T("keys.enter")) + " Toggle/" + i18n.T("actions.edit") + "   "
+		if m.selectedItem >= 3 {
+			help += KeyStyle.Render("a") + " " + i18n.T("settings.cycle_action") + "   "
+		}
 		help += KeyStyle.Render(i18n.T("keys.esc")) + " " + i18n.T("actions.back")
 	}
 	s.WriteString(HelpStyle.Render(help))
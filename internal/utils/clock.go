@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockPattern matches "23:30", "7:05", "7", "7am", "11:30pm"
+var clockPattern = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+
+// Target is a parsed timer input, either a relative duration or an absolute time
+type Target struct {
+	Minutes int       // Relative duration, used when At is zero
+	At      time.Time // Absolute wall-clock time
+}
+
+// IsAbsolute reports whether the target is a wall-clock time
+func (t Target) IsAbsolute() bool {
+	return !t.At.IsZero()
+}
+
+// EndTime resolves the target to the moment the timer should fire
+func (t Target) EndTime(now time.Time) time.Time {
+	if t.IsAbsolute() {
+		return t.At
+	}
+	return now.Add(time.Duration(t.Minutes) * time.Minute)
+}
+
+// ParseTarget parses either a duration (see ParseDuration) or an absolute
+// wall-clock time (see ParseClockTime)
+func ParseTarget(input string, now time.Time) (Target, error) {
+	if IsClockTime(input) {
+		at, err := ParseClockTime(input, now)
+		if err != nil {
+			return Target{}, err
+		}
+		return Target{At: at}, nil
+	}
+
+	minutes, err := ParseDuration(input)
+	if err != nil {
+		return Target{}, err
+	}
+	return Target{Minutes: minutes}, nil
+}
+
+// IsClockTime reports whether input uses the absolute time syntax
+func IsClockTime(input string) bool {
+	input = strings.ToLower(strings.TrimSpace(input))
+	return strings.HasPrefix(input, "@") ||
+		strings.HasPrefix(input, "at ") ||
+		strings.HasPrefix(input, "today ") ||
+		strings.HasPrefix(input, "tomorrow ")
+}
+
+// ParseClockTime parses an absolute wall-clock time in now's location
+// Supported formats:
+// - "@23:30", "at 23:30" -> next 23:30, today or tomorrow
+// - "tomorrow 07:00", "@tomorrow 7:00" -> 07:00 tomorrow
+// - "today 23:30" -> 23:30 today, error if already passed
+// - "@11:30pm", "at 7am" -> 12-hour clock
+// - "@7" -> next 07:00
+//
+// A wall time repeated when clocks fall back resolves to its first
+// occurrence, or to the second one once the first has passed.
+func ParseClockTime(input string, now time.Time) (time.Time, error) {
+	original := input
+	input = strings.ToLower(strings.TrimSpace(input))
+	input = strings.TrimPrefix(input, "@")
+	input = strings.TrimSpace(strings.TrimPrefix(input, "at "))
+
+	day := ""
+	for _, prefix := range []string{"today", "tomorrow"} {
+		if strings.HasPrefix(input, prefix+" ") {
+			day = prefix
+			input = strings.TrimSpace(strings.TrimPrefix(input, prefix))
+		}
+	}
+
+	hour, minute, err := parseClock(input)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time format: %s", original)
+	}
+
+	y, m, d := now.Date()
+	switch day {
+	case "tomorrow":
+		return WallClock(y, m, d+1, hour, minute, now.Location()), nil
+	case "today":
+		at, ok := nextWallClock(y, m, d, hour, minute, now)
+		if !ok {
+			return time.Time{}, fmt.Errorf("time has already passed: %s", original)
+		}
+		return at, nil
+	}
+
+	// Next matching instant, rolling over to tomorrow if already passed
+	if at, ok := nextWallClock(y, m, d, hour, minute, now); ok {
+		return at, nil
+	}
+	return WallClock(y, m, d+1, hour, minute, now.Location()), nil
+}
+
+// nextWallClock returns the first instant after now at which the local clock
+// shows hour:minute on the given day, ok is false when none is left
+func nextWallClock(year int, month time.Month, day, hour, minute int, now time.Time) (time.Time, bool) {
+	at := WallClock(year, month, day, hour, minute, now.Location())
+	if at.After(now) {
+		return at, true
+	}
+
+	// When clocks fall back after at, the same wall time comes around again
+	_, end := at.ZoneBounds()
+	if end.IsZero() {
+		return time.Time{}, false
+	}
+	_, offset := at.Zone()
+	_, offsetAfter := end.Zone()
+	if offsetAfter >= offset {
+		return time.Time{}, false
+	}
+	later := at.Add(time.Duration(offset-offsetAfter) * time.Second)
+	if later.Before(end) || later.Hour() != hour || later.Minute() != minute || !later.After(now) {
+		return time.Time{}, false
+	}
+	return later, true
+}
+
+// ParseClock parses a clock such as "23:30" or "11:30pm" into hour and minute
+func ParseClock(input string) (int, int, error) {
+	hour, minute, err := parseClock(strings.ToLower(strings.TrimSpace(input)))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time format: %s", input)
+	}
+	return hour, minute, nil
+}
+
+// parseClock parses a 24-hour or 12-hour clock into hour and minute
+func parseClock(input string) (int, int, error) {
+	parts := clockPattern.FindStringSubmatch(input)
+	if parts == nil {
+		return 0, 0, fmt.Errorf("invalid clock")
+	}
+
+	hour, _ := strconv.Atoi(parts[1])
+	minute := 0
+	if parts[2] != "" {
+		minute, _ = strconv.Atoi(parts[2])
+	}
+
+	if parts[3] != "" {
+		if hour < 1 || hour > 12 {
+			return 0, 0, fmt.Errorf("invalid hour")
+		}
+		hour %= 12
+		if parts[3] == "pm" {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || minute > 59 {
+		return 0, 0, fmt.Errorf("out of range")
+	}
+	return hour, minute, nil
+}
+
+// WallClock returns the instant the local clock shows hour:minute on the
+// given day. Wall times skipped by a DST jump resolve to the moment of the jump,
+// and wall times repeated when clocks fall back resolve to the first occurrence.
+func WallClock(year int, month time.Month, day, hour, minute int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, minute, 0, 0, loc)
+
+	if t.Hour() != hour || t.Minute() != minute {
+		// The wall time does not exist, time.Date shifted it across the gap
+		wanted := time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+		got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+		start, end := t.ZoneBounds()
+		if got.After(wanted) {
+			return start
+		}
+		return end
+	}
+
+	// If the zone period started with clocks falling back, the same wall
+	// time may also exist just before the transition
+	start, _ := t.ZoneBounds()
+	if !start.IsZero() {
+		_, offsetBefore := start.Add(-time.Second).Zone()
+		_, offset := t.Zone()
+		if offsetBefore > offset {
+			earlier := t.Add(-time.Duration(offsetBefore-offset) * time.Second)
+			if earlier.Before(start) && earlier.Hour() == hour && earlier.Minute() == minute {
+				return earlier
+			}
+		}
+	}
+
+	return t
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseClockTimeFallBack(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data missing: %v", err)
+	}
+	// Clocks fall back from 02:00 EDT to 01:00 EST on 2 November 2025
+	utc := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 11, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		input string
+		now   time.Time
+		want  time.Time
+	}{
+		{input: "@1:30", now: utc(2, 5, 0), want: utc(2, 5, 30)},          // 01:00 EDT, first occurrence
+		{input: "@1:30", now: utc(2, 5, 40), want: utc(2, 6, 30)},         // 01:40 EDT, second occurrence
+		{input: "today 1:30", now: utc(2, 5, 40), want: utc(2, 6, 30)},    // 01:40 EDT
+		{input: "@1:50", now: utc(2, 5, 40), want: utc(2, 5, 50)},         // 01:40 EDT, first occurrence
+		{input: "@1:30", now: utc(2, 6, 40), want: utc(3, 6, 30)},         // 01:40 EST, both passed
+		{input: "@0:30", now: utc(2, 5, 40), want: utc(3, 5, 30)},         // 01:40 EDT, not repeated
+		{input: "tomorrow 1:30", now: utc(1, 5, 40), want: utc(2, 5, 30)}, // first occurrence
+	}
+
+	for _, tt := range tests {
+		now := tt.now.In(loc)
+		got, err := ParseClockTime(tt.input, now)
+		if err != nil {
+			t.Errorf("%q at %s: %v", tt.input, now.Format("15:04 MST"), err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%q at %s = %s, want %s", tt.input, now.Format("15:04 MST"), got.Format(time.RFC3339), tt.want.In(loc).Format(time.RFC3339))
+		}
+	}
+
+	// Both occurrences have passed
+	if _, err := ParseClockTime("today 1:30", utc(2, 6, 40).In(loc)); err == nil {
+		t.Error("today 1:30 at 01:40 EST succeeded")
+	}
+}
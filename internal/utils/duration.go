@@ -93,3 +93,35 @@ func FormatCountdown(d time.Duration) string {
 func GenerateID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
 }
+
+// ParseAdjustment parses a signed duration used to extend or shorten a timer
+// Supported formats:
+// - "15", "+15m", "1h", "1:20" -> extend, bare numbers are minutes
+// - "30s", "1m30s" -> extend by seconds
+// - "-5", "-5m", "-45s" -> shorten
+func ParseAdjustment(input string) (time.Duration, error) {
+	input = strings.TrimSpace(input)
+	sign := time.Duration(1)
+	if strings.HasPrefix(input, "-") {
+		sign = -1
+	}
+	input = strings.TrimLeft(input, "+-")
+
+	var delta time.Duration
+	if val, err := strconv.Atoi(input); err == nil {
+		delta = time.Duration(val) * time.Minute
+	} else if strings.Contains(input, ":") {
+		minutes, err := ParseDuration(input)
+		if err != nil {
+			return 0, err
+		}
+		delta = time.Duration(minutes) * time.Minute
+	} else if delta, err = time.ParseDuration(input); err != nil {
+		return 0, fmt.Errorf("invalid duration format: %s", input)
+	}
+
+	if delta == 0 {
+		return 0, fmt.Errorf("duration must not be zero")
+	}
+	return sign * delta, nil
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAdjustment(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "15", want: 15 * time.Minute},
+		{input: "+15m", want: 15 * time.Minute},
+		{input: "1h", want: time.Hour},
+		{input: "+1h30m", want: 90 * time.Minute},
+		{input: "1:20", want: 80 * time.Minute},
+		{input: "30s", want: 30 * time.Second},
+		{input: "90s", want: 90 * time.Second},
+		{input: "1m30s", want: 90 * time.Second},
+		{input: "-5", want: -5 * time.Minute},
+		{input: "-5m", want: -5 * time.Minute},
+		{input: "-45s", want: -45 * time.Second},
+		{input: " -45s ", want: -45 * time.Second},
+		{input: "0", wantErr: true},
+		{input: "0s", wantErr: true},
+		{input: "-0m", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "soon", wantErr: true},
+		{input: "5x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAdjustment(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAdjustment(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAdjustment(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
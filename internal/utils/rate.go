@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// rateUnits maps the prefixes accepted by ParseRate to their multipliers
+var rateUnits = map[string]float64{
+	"":  1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+}
+
+// ParseRate parses a throughput and returns bytes per second
+// Supported formats:
+// - "500" -> 500 bytes/s
+// - "50k", "50K", "50KB", "50KB/s" -> 51200 bytes/s
+// - "1.5M", "1.5MB/s" -> 1572864 bytes/s
+func ParseRate(input string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	s = strings.TrimSuffix(s, "/s")
+	s = strings.TrimSuffix(s, "b")
+	if s == "" {
+		return 0, fmt.Errorf("empty rate")
+	}
+
+	unit := ""
+	if last := s[len(s)-1:]; last < "0" || last > "9" {
+		unit, s = last, s[:len(s)-1]
+	}
+	multiplier, ok := rateUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid rate format: %s", input)
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate format: %s", input)
+	}
+	rate := int64(value * multiplier)
+	if rate <= 0 {
+		return 0, fmt.Errorf("rate must be positive")
+	}
+	return rate, nil
+}
+
+// FormatRate formats bytes per second into a readable string like "1.5 MB/s"
+func FormatRate(bytesPerSec float64) string {
+	switch {
+	case bytesPerSec >= 1<<30:
+		return formatUnit(bytesPerSec/(1<<30), "GB/s")
+	case bytesPerSec >= 1<<20:
+		return formatUnit(bytesPerSec/(1<<20), "MB/s")
+	case bytesPerSec >= 1<<10:
+		return formatUnit(bytesPerSec/(1<<10), "KB/s")
+	default:
+		return fmt.Sprintf("%.0f B/s", bytesPerSec)
+	}
+}
+
+// formatUnit formats value with one decimal, dropping a trailing ".0"
+func formatUnit(value float64, unit string) string {
+	return strings.TrimSuffix(strconv.FormatFloat(value, 'f', 1, 64), ".0") + " " + unit
+}